@@ -0,0 +1,166 @@
+// cmd/algorithms.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/jump"
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+var algorithmsCmd = &cobra.Command{
+	Use:     "algorithms [flags] [user@]hostname[:port]",
+	Aliases: []string{"algos"},
+	Short:   "Show the algorithms negotiated with a server",
+	Long: `Connect to a server and show the negotiated key exchange, host key,
+cipher and MAC algorithms. The per-host algorithm settings and the
+-c/-m/--kex/--host-key-algorithms flags are applied, so this can be used to
+test an algorithm list before saving it.
+
+Algorithm lists use the OpenSSH syntax: a comma separated list replaces the
+defaults, a leading '+' appends to them, '-' removes from them and '^' puts
+the algorithms first.
+
+Examples:
+  ssm algorithms user@host                               # Show negotiated algorithms
+  ssm algorithms --kex +diffie-hellman-group1-sha1 old-switch
+  ssm algorithms --supported                             # List algorithms supported by ssm`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runAlgorithmsCommand,
+}
+
+func init() {
+	algorithmsCmd.Flags().StringP("identity", "i", "", "Private key file for authentication")
+	algorithmsCmd.Flags().StringP("proxy-jump", "J", "", "Connect via jump hosts, saved per host. Format: [user@]hostname[:port][,...] or alias, 'none' to clear")
+	algorithmsCmd.Flags().Bool("supported", false, "List the algorithms supported by ssm")
+	addAlgorithmFlags(algorithmsCmd)
+
+	rootCmd.AddCommand(algorithmsCmd)
+}
+
+// addAlgorithmFlags 添加算法相关的命令行参数
+func addAlgorithmFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("cipher", "c", "", "Ciphers, comma separated (prefix +, - or ^ to modify the defaults)")
+	cmd.Flags().StringP("mac", "m", "", "MAC algorithms, comma separated (prefix +, - or ^ to modify the defaults)")
+	cmd.Flags().String("kex", "", "Key exchange algorithms, comma separated (prefix +, - or ^ to modify the defaults)")
+	cmd.Flags().String("host-key-algorithms", "", "Host key algorithms, comma separated (prefix +, - or ^ to modify the defaults)")
+}
+
+// applyAlgorithmFlags 将显式指定的算法参数写入配置（连接成功后随配置保存）
+func applyAlgorithmFlags(cmd *cobra.Command, cfg *config.SSHConfig) {
+	if cmd.Flags().Changed("cipher") {
+		cfg.Ciphers, _ = cmd.Flags().GetString("cipher")
+	}
+	if cmd.Flags().Changed("mac") {
+		cfg.MACs, _ = cmd.Flags().GetString("mac")
+	}
+	if cmd.Flags().Changed("kex") {
+		cfg.KeyExchanges, _ = cmd.Flags().GetString("kex")
+	}
+	if cmd.Flags().Changed("host-key-algorithms") {
+		cfg.HostKeyAlgorithms, _ = cmd.Flags().GetString("host-key-algorithms")
+	}
+}
+
+func runAlgorithmsCommand(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	if supported, _ := cmd.Flags().GetBool("supported"); supported {
+		displaySupportedAlgorithms()
+		return
+	}
+
+	if len(args) < 1 {
+		cmd.Help()
+		return
+	}
+
+	username, hostname, port := a.resolveHostArg(args[0])
+	privateKeyPath, _ := cmd.Flags().GetString("identity")
+	proxyJump, _ := cmd.Flags().GetString("proxy-jump")
+
+	key := utils.GetConfigKey(username, hostname, port)
+	sshConfig, exists := a.store.Get(key)
+	if !exists {
+		sshConfig = &config.SSHConfig{
+			Host:     hostname,
+			Username: username,
+			Port:     port,
+		}
+	}
+	config.ApplyPatterns(a.store, sshConfig)
+	if privateKeyPath != "" {
+		sshConfig.PrivateKey = privateKeyPath
+	}
+	applyProxyJump(sshConfig, proxyJump)
+	applyAlgorithmFlags(cmd, sshConfig)
+
+	clientConfig, err := a.auth.CreateClientConfig(sshConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create SSH config: %v\n", err)
+		os.Exit(1)
+	}
+
+	client, err := jump.Dial(a.store, a.auth, sshConfig, clientConfig, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	conn, ok := client.Conn.(ssh.AlgorithmsConnMetadata)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Negotiated algorithms are not available for this connection\n")
+		os.Exit(1)
+	}
+
+	algorithms := conn.Algorithms()
+	fmt.Printf("Negotiated with %s (%s):\n", sshConfig.GetKey(), string(client.ServerVersion()))
+	fmt.Printf("  kex:              %s\n", algorithms.KeyExchange)
+	fmt.Printf("  host key:         %s\n", algorithms.HostKey)
+	fmt.Printf("  cipher (c->s):    %s\n", algorithms.Write.Cipher)
+	fmt.Printf("  cipher (s->c):    %s\n", algorithms.Read.Cipher)
+	fmt.Printf("  mac (c->s):       %s\n", formatMAC(algorithms.Write.MAC))
+	fmt.Printf("  mac (s->c):       %s\n", formatMAC(algorithms.Read.MAC))
+}
+
+// formatMAC AEAD 加密算法不使用单独的 MAC
+func formatMAC(mac string) string {
+	if mac == "" {
+		return "<implicit>"
+	}
+	return mac
+}
+
+// displaySupportedAlgorithms 显示 ssm 支持的算法，存在安全问题的算法单独标出
+func displaySupportedAlgorithms() {
+	supported := ssh.SupportedAlgorithms()
+	insecure := ssh.InsecureAlgorithms()
+
+	sections := []struct {
+		title    string
+		secure   []string
+		insecure []string
+	}{
+		{"Key exchange (--kex)", supported.KeyExchanges, insecure.KeyExchanges},
+		{"Ciphers (-c)", supported.Ciphers, insecure.Ciphers},
+		{"MACs (-m)", supported.MACs, insecure.MACs},
+		{"Host key algorithms (--host-key-algorithms)", supported.HostKeys, insecure.HostKeys},
+	}
+
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s:\n", section.title)
+		fmt.Printf("  %s\n", strings.Join(section.secure, "\n  "))
+		for _, algo := range section.insecure {
+			fmt.Printf("  %s (insecure, legacy only)\n", algo)
+		}
+	}
+}
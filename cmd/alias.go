@@ -0,0 +1,118 @@
+// cmd/alias.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage host aliases",
+	Long: `Manage host aliases. An alias is a unique short name for a saved connection
+that can be used instead of [user@]hostname[:port], for example with ssm, cp,
+copy-id and -J.
+
+Examples:
+  ssm alias set prod-db root@10.0.0.5:2222   # Create or update an alias
+  ssm prod-db                                # Connect using the alias
+  ssm cp backup.sql prod-db:/tmp/            # Copy using the alias
+  ssm alias rm prod-db                       # Remove the alias
+  ssm alias list                             # List aliases`,
+}
+
+var aliasSetCmd = &cobra.Command{
+	Use:   "set alias [user@]hostname[:port]",
+	Short: "Set the alias of a connection (the connection is created if needed)",
+	Args:  cobra.ExactArgs(2),
+	Run:   runAliasSet,
+}
+
+var aliasRmCmd = &cobra.Command{
+	Use:     "rm alias",
+	Aliases: []string{"remove"},
+	Short:   "Remove an alias",
+	Args:    cobra.ExactArgs(1),
+	Run:     runAliasRm,
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List aliases",
+	Args:    cobra.NoArgs,
+	Run:     runAliasList,
+}
+
+func init() {
+	aliasCmd.AddCommand(aliasSetCmd)
+	aliasCmd.AddCommand(aliasRmCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+
+	rootCmd.AddCommand(aliasCmd)
+}
+
+func runAliasSet(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	alias := args[0]
+	username, hostname, port := a.resolveHostArg(args[1])
+
+	sshConfig := &config.SSHConfig{
+		Host:     hostname,
+		Username: username,
+		Port:     port,
+	}
+	if err := config.SetAlias(a.store, sshConfig, alias); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Alias %s -> %s\n", alias, sshConfig.GetKey())
+}
+
+func runAliasRm(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	key, err := config.RemoveAlias(a.store, args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed alias %s from %s\n", args[0], key)
+}
+
+func runAliasList(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	configs, err := a.store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configs: %v\n", err)
+		os.Exit(1)
+	}
+
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Alias < configs[j].Alias
+	})
+	found := false
+	for _, cfg := range configs {
+		if cfg.Alias != "" {
+			fmt.Printf("%-20s %s\n", cfg.Alias, cfg.GetKey())
+			found = true
+		}
+	}
+	if !found {
+		fmt.Println("No aliases found.")
+	}
+}
+
+// resolveHostArg 解析主机参数，先按别名查找已保存的配置，再按 [user@]hostname[:port] 解析，
+// 未指定的用户和端口使用匹配模式的设置
+func (a *app) resolveHostArg(host string) (username, hostname, port string) {
+	if sshConfig, exists := config.GetByAlias(a.store, host); exists {
+		return sshConfig.Username, sshConfig.Host, sshConfig.Port
+	}
+	username, hostname, port, _ = config.ResolveAddress(a.store, host)
+	return username, hostname, port
+}
@@ -0,0 +1,54 @@
+// cmd/alias_test.go
+package cmd
+
+import (
+	"testing"
+
+	"github.com/wuxs/ssm/pkg/auth"
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+// newTestApp 创建使用内存配置存储的应用，不读写 ~/.ssm
+func newTestApp(t *testing.T, configs ...config.SSHConfig) *app {
+	t.Helper()
+	return &app{
+		store: config.NewMemoryStore(configs...),
+		auth:  auth.NewAuthManager(t.TempDir()),
+	}
+}
+
+func TestResolveHostArg(t *testing.T) {
+	a := newTestApp(t, config.SSHConfig{Alias: "prod", Host: "10.0.0.1", Username: "deploy", Port: "2222"})
+
+	tests := []struct {
+		host                     string
+		username, hostname, port string
+	}{
+		{"prod", "deploy", "10.0.0.1", "2222"},
+		{"admin@prod", "admin", "prod", "22"},
+		{"root@10.0.0.2:2200", "root", "10.0.0.2", "2200"},
+	}
+	for _, tt := range tests {
+		username, hostname, port := a.resolveHostArg(tt.host)
+		if username != tt.username || hostname != tt.hostname || port != tt.port {
+			t.Errorf("resolveHostArg(%q) = %s, %s, %s, want %s, %s, %s",
+				tt.host, username, hostname, port, tt.username, tt.hostname, tt.port)
+		}
+	}
+}
+
+func TestHandleDeleteByAlias(t *testing.T) {
+	a := newTestApp(t,
+		config.SSHConfig{Alias: "prod", Host: "10.0.0.1", Username: "deploy", Port: "22"},
+		config.SSHConfig{Host: "10.0.0.2", Username: "deploy", Port: "22"},
+	)
+
+	a.handleDelete("prod")
+
+	if _, exists := a.store.Get("deploy@10.0.0.1:22"); exists {
+		t.Error("config was not deleted by its alias")
+	}
+	if _, exists := a.store.Get("deploy@10.0.0.2:22"); !exists {
+		t.Error("unrelated config was deleted")
+	}
+}
@@ -0,0 +1,245 @@
+// cmd/bundle.go
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/auth"
+	"github.com/wuxs/ssm/pkg/bundle"
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+// maxBundleAttempts 输入清单口令的最大尝试次数
+const maxBundleAttempts = 3
+
+func runExportBundle(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	path, _ := cmd.Flags().GetString("bundle")
+	if path == "" {
+		cmd.Help()
+		return
+	}
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	encrypt, _ := cmd.Flags().GetBool("encrypt")
+	secrets, _ := cmd.Flags().GetBool("secrets")
+
+	if secrets && !encrypt {
+		fmt.Fprintf(os.Stderr, "Error: --secrets requires --encrypt, passwords and keys are never written in plain text\n")
+		os.Exit(1)
+	}
+
+	configs, err := a.store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configs: %v\n", err)
+		os.Exit(1)
+	}
+	configs = bundle.Select(configs, tags)
+	hosts, patterns := config.SplitPatterns(configs)
+	if len(hosts) == 0 {
+		fmt.Println("No hosts to export.")
+		return
+	}
+
+	if secrets {
+		for i := range configs {
+			if err := a.auth.LoadSavedPassword(&configs[i]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to read the password of %s: %v\n", configs[i].GetKey(), err)
+				os.Exit(1)
+			}
+		}
+	}
+	b, err := bundle.New(configs, secrets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var passphrase string
+	if encrypt {
+		if passphrase, err = promptNewPassphrase("bundle"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	data, err := bundle.Marshal(b, passphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	contents := "secrets stripped"
+	if secrets {
+		contents = fmt.Sprintf("with passwords and %s", pluralize(len(b.Keys), "key file"))
+	}
+	if encrypt {
+		contents = "encrypted, " + contents
+	}
+	fmt.Printf("Exported %s and %s to %s (%s)\n", pluralize(len(hosts), "host"), pluralize(len(patterns), "pattern"), path, contents)
+}
+
+func runImportBundle(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	path, _ := cmd.Flags().GetString("bundle")
+	if path == "" {
+		cmd.Help()
+		return
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	conflict, _ := cmd.Flags().GetString("conflict")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	if conflict != "skip" && conflict != "overwrite" && conflict != "newest" {
+		fmt.Fprintf(os.Stderr, "Error: Invalid --conflict %q, use skip, overwrite or newest\n", conflict)
+		os.Exit(1)
+	}
+
+	b, err := openBundle(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := config.Snapshot(a.store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configs: %v\n", err)
+		os.Exit(1)
+	}
+
+	changes := planBundleImport(b, store, conflict, filepath.Join(config.GetConfigDir(), "keys"))
+	if len(changes) == 0 {
+		fmt.Printf("No hosts found in %s\n", path)
+		return
+	}
+
+	contents := "secrets stripped"
+	if b.Secrets {
+		contents = "with secrets"
+	}
+	fmt.Printf("Importing from %s (created %s, %s):\n", path, b.CreatedAt, contents)
+	pending := printImportPlan(changes)
+	if confirmImport(pending, dryRun, yes) {
+		a.applyImport(changes, pending)
+	}
+}
+
+// openBundle 读取清单文件，加密的清单提示输入口令
+func openBundle(path string) (*bundle.Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := bundle.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if !file.IsEncrypted() {
+		return file.Open("")
+	}
+
+	for attempt := 1; ; attempt++ {
+		passphrase, err := auth.PromptPassword("Bundle passphrase: ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase: %v", err)
+		}
+
+		b, err := file.Open(passphrase)
+		if !errors.Is(err, bundle.ErrWrongPassphrase) || attempt == maxBundleAttempts {
+			return b, err
+		}
+		fmt.Println("Wrong bundle passphrase, please try again.")
+	}
+}
+
+// planBundleImport 计算清单中每个配置的导入结果。密钥文件保存到 keyDir，
+// conflict 为 skip、overwrite 或 newest（清单中的配置最后使用时间更晚时覆盖）
+func planBundleImport(b *bundle.Bundle, store *config.ConfigStore, conflict, keyDir string) []importChange {
+	targets := b.LocalKeys(keyDir)
+
+	var changes []importChange
+	for _, cfg := range b.Hosts {
+		imported := cfg
+		imported.Tags = append([]string(nil), cfg.Tags...)
+		key := imported.GetKey()
+
+		change := importChange{alias: key, result: &imported}
+		if imported.Alias != "" {
+			change.alias = imported.Alias
+		}
+		for _, path := range []*string{&imported.PrivateKey, &imported.Certificate} {
+			if target, ok := targets[*path]; ok {
+				if change.keys == nil {
+					change.keys = make(map[string]string)
+				}
+				change.keys[target] = b.Keys[*path]
+				*path = target
+			} else {
+				*path = bundle.LocalPath(*path)
+			}
+		}
+
+		// 别名已被其他配置使用时不导入别名
+		if owner, ok := store.FindAlias(imported.Alias); ok && imported.Alias != "" && owner != key {
+			change.warning = fmt.Sprintf("alias %s is already used by %s, not imported", imported.Alias, owner)
+			imported.Alias = ""
+		}
+
+		existing, ok := store.Items[key]
+		if !ok {
+			change.diffs = diffConfigs(&config.SSHConfig{}, &imported)
+			change.apply = true
+			changes = append(changes, change)
+			continue
+		}
+
+		change.existing = &existing
+		if imported.Alias == "" {
+			imported.Alias = existing.Alias
+		}
+		// 清单中没有密码时保留已保存的密码；有密码时沿用保险库条目
+		if imported.Password == "" {
+			imported.Password = existing.Password
+		}
+		imported.PasswordRef = existing.PasswordRef
+		newer := parseLastUsed(&cfg).After(parseLastUsed(&existing))
+		if !newer {
+			imported.LastUsed = existing.LastUsed
+		}
+
+		change.diffs = diffConfigs(&existing, &imported)
+		switch conflict {
+		case "skip":
+			change.action = "conflicts with existing config, skipped"
+		case "overwrite":
+			change.action = "overwrites existing config"
+			change.apply = len(change.diffs) > 0
+		case "newest":
+			if newer {
+				change.action = fmt.Sprintf("used more recently on %s, overwrites existing config", formatLastUsed(cfg.LastUsed))
+				change.apply = len(change.diffs) > 0
+			} else {
+				change.action = "existing config is newer, skipped"
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// formatLastUsed 最后使用时间显示为本地日期时间
+func formatLastUsed(lastUsed string) string {
+	t, err := time.Parse(time.RFC3339, lastUsed)
+	if err != nil {
+		return lastUsed
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,44 @@
+// cmd/bundle_test.go
+package cmd
+
+import (
+	"testing"
+
+	"github.com/wuxs/ssm/pkg/bundle"
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+func TestPlanBundleImport(t *testing.T) {
+	store := &config.ConfigStore{Items: map[string]config.SSHConfig{
+		"root@10.0.0.1:22": {Host: "10.0.0.1", Username: "root", Port: "22", PasswordRef: "pw-1", LastUsed: "2026-03-01T00:00:00Z"},
+		"root@10.0.0.2:22": {Host: "10.0.0.2", Username: "root", Port: "22", LastUsed: "2026-03-01T00:00:00Z"},
+		"root@10.0.0.3:22": {Alias: "db", Host: "10.0.0.3", Username: "root", Port: "22"},
+	}}
+	b := &bundle.Bundle{Hosts: []config.SSHConfig{
+		{Host: "10.0.0.1", Username: "root", Port: "22", Notes: "older", LastUsed: "2026-02-01T00:00:00Z"},
+		{Host: "10.0.0.2", Username: "root", Port: "22", Notes: "newer", LastUsed: "2026-04-01T00:00:00Z"},
+		{Alias: "db", Host: "10.0.0.4", Username: "root", Port: "22"},
+	}}
+
+	tests := map[string][]bool{
+		"skip":      {false, false, true},
+		"overwrite": {true, true, true},
+		"newest":    {false, true, true},
+	}
+	for conflict, want := range tests {
+		changes := planBundleImport(b, store, conflict, t.TempDir())
+		for i, change := range changes {
+			if change.apply != want[i] {
+				t.Errorf("%s: %s apply = %v, want %v", conflict, change.result.GetKey(), change.apply, want[i])
+			}
+		}
+
+		first := changes[0].result
+		if first.PasswordRef != "pw-1" || first.LastUsed != "2026-03-01T00:00:00Z" {
+			t.Errorf("%s: %+v, want the saved password and newer last_used kept", conflict, first)
+		}
+		if changes[2].result.Alias != "" || changes[2].warning == "" {
+			t.Errorf("%s: alias used by another config was imported: %+v", conflict, changes[2].result)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// cmd/cert.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/wuxs/ssm/pkg/auth"
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+var certCmd = &cobra.Command{
+	Use:   "cert [flags] [certificate...]",
+	Short: "Inspect OpenSSH user certificates",
+	Long: `Show the details of OpenSSH user certificates: key ID, serial, signing CA,
+principals, validity period, critical options and extensions.
+
+Without arguments, inspects the certificates ssm would use for authentication:
+the certificate next to the key given by -i (<key>-cert.pub) and the
+certificates next to the default keys in ~/.ssh.
+
+Examples:
+  ssm cert                                   # Inspect discovered certificates
+  ssm cert ~/.ssh/id_ed25519-cert.pub        # Inspect a certificate file
+  ssm cert -i ~/.ssh/work_key                # Inspect ~/.ssh/work_key-cert.pub`,
+	Run: runCertCommand,
+}
+
+func init() {
+	certCmd.Flags().StringP("identity", "i", "", "Private key whose certificate should be inspected")
+
+	rootCmd.AddCommand(certCmd)
+}
+
+func runCertCommand(cmd *cobra.Command, args []string) {
+	certFiles := args
+	if len(certFiles) == 0 {
+		privateKeyPath, _ := cmd.Flags().GetString("identity")
+		certFiles = auth.GetCertificateFiles(&config.SSHConfig{PrivateKey: privateKeyPath})
+	}
+
+	if len(certFiles) == 0 {
+		fmt.Println("No certificates found.")
+		return
+	}
+
+	failed := false
+	for i, certPath := range certFiles {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		cert, err := auth.LoadCertificate(certPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", certPath, err)
+			failed = true
+			continue
+		}
+		displayCertificate(certPath, cert)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// displayCertificate 显示证书详情，格式参照 ssh-keygen -L
+func displayCertificate(certPath string, cert *ssh.Certificate) {
+	certType := "user"
+	if cert.CertType == ssh.HostCert {
+		certType = "host"
+	}
+
+	status := "valid"
+	if err := auth.CheckCertificateValidity(cert, time.Now()); err != nil {
+		status = err.Error()
+	}
+
+	fmt.Printf("%s:\n", certPath)
+	fmt.Printf("        Type: %s %s certificate\n", cert.Type(), certType)
+	fmt.Printf("        Public key: %s %s\n", cert.Key.Type(), ssh.FingerprintSHA256(cert.Key))
+	fmt.Printf("        Signing CA: %s %s\n", cert.SignatureKey.Type(), ssh.FingerprintSHA256(cert.SignatureKey))
+	fmt.Printf("        Key ID: %q\n", cert.KeyId)
+	fmt.Printf("        Serial: %d\n", cert.Serial)
+	fmt.Printf("        Valid: %s\n", formatCertificateValidity(cert))
+	fmt.Printf("        Status: %s\n", status)
+
+	fmt.Printf("        Principals:")
+	if len(cert.ValidPrincipals) == 0 {
+		fmt.Println(" (none)")
+	} else {
+		fmt.Println()
+		for _, principal := range cert.ValidPrincipals {
+			fmt.Printf("                %s\n", principal)
+		}
+	}
+
+	printCertificateOptions("Critical Options", cert.CriticalOptions)
+	printCertificateOptions("Extensions", cert.Extensions)
+}
+
+// formatCertificateValidity 格式化证书有效期
+func formatCertificateValidity(cert *ssh.Certificate) string {
+	if cert.ValidAfter == 0 && cert.ValidBefore == ssh.CertTimeInfinity {
+		return "forever"
+	}
+
+	from := "always"
+	if cert.ValidAfter != 0 {
+		from = time.Unix(int64(cert.ValidAfter), 0).Format(time.RFC3339)
+	}
+	to := "forever"
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		to = time.Unix(int64(cert.ValidBefore), 0).Format(time.RFC3339)
+	}
+	return fmt.Sprintf("from %s to %s", from, to)
+}
+
+// printCertificateOptions 按名称排序显示证书选项
+func printCertificateOptions(title string, options map[string]string) {
+	fmt.Printf("        %s:", title)
+	if len(options) == 0 {
+		fmt.Println(" (none)")
+		return
+	}
+	fmt.Println()
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if value := strings.TrimSpace(options[name]); value != "" {
+			fmt.Printf("                %s %s\n", name, value)
+		} else {
+			fmt.Printf("                %s\n", name)
+		}
+	}
+}
@@ -0,0 +1,604 @@
+// cmd/config.go
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/auth"
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and edit connection configs",
+	Long: `Inspect and edit saved connection configs without connecting. Hosts are given
+by alias or [user@]hostname[:port]; fields use the names shown by 'ssm config get'
+and 'ssm --list --columns' (private_key, port, proxy_jump, tags, ...).
+
+Examples:
+  ssm config add deploy@10.0.0.5 alias=prod-db private_key=~/.ssh/prod_key
+  ssm config set prod-db private_key=~/.ssh/new_key forward_agent=yes
+  ssm config set prod-db --password          # Prompt for a password and save it
+  ssm config get prod-db
+  ssm config get prod-db proxy_jump
+  ssm config unset prod-db password          # Clear the saved password
+  ssm config rename prod-db 10.0.0.9         # The host moved to a new IP
+  ssm config copy prod-db :2222              # Clone the entry to another port`,
+}
+
+var configAddCmd = &cobra.Command{
+	Use:   "add [user@]hostname[:port] [field=value...]",
+	Short: "Save a new connection config",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runConfigAdd,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set host field=value...",
+	Short: "Change fields of a saved config",
+	Long: `Change fields of a saved config. Key and certificate files must exist, ports
+must be between 1 and 65535, and booleans accept yes/no. Changing host,
+username or port moves the config to its new key, like 'ssm config rename'.
+Passwords are not accepted on the command line, use --password to be prompted.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runConfigSet,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get host [field]",
+	Short: "Show the saved fields of a config, or the value of one field",
+	Args:  cobra.RangeArgs(1, 2),
+	Run:   runConfigGet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset host field...",
+	Short: "Clear fields of a saved config",
+	Long: `Clear fields of a saved config. Unsetting password (or password_ref) also
+removes the password from the vault. Host, username and port cannot be unset.`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  runConfigUnset,
+}
+
+var configRenameCmd = &cobra.Command{
+	Use:   "rename host [user@][hostname][:port]",
+	Short: "Move a saved config to a new key",
+	Long: `Move a saved config to a new user, hostname or port, keeping its alias and
+other fields. Parts left out of the new address are kept, so
+'ssm config rename prod-db 10.0.0.9' only changes the hostname. Configs that
+use the old address as a jump host are updated to the new one.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runConfigRename,
+}
+
+var configCopyCmd = &cobra.Command{
+	Use:   "copy host [user@][hostname][:port]",
+	Short: "Copy a saved config to a new key",
+	Long: `Copy a saved config to a new user, hostname or port. Parts left out of the
+new address are kept, so 'ssm config copy prod-db :2222' clones the entry to
+port 2222. The copy has no alias (aliases are unique) and has never been used.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runConfigCopy,
+}
+
+var configResolveCmd = &cobra.Command{
+	Use:   "resolve host",
+	Short: "Show the effective config of a host and where each field came from",
+	Long: `Show the effective config of a host (alias or [user@]hostname[:port]): the
+saved config merged with the matching patterns, and where each field came from.
+
+Fields are taken, highest precedence first, from:
+  1. command line flags (-i, -J, ...)
+  2. the saved config of the host
+  3. matching patterns, most specific first: CIDRs before globs, longer
+     prefixes before shorter ones, and globs with more literal characters
+     before globs with fewer
+  4. defaults ($USER, port 22, ssh-agent keys and ~/.ssh/id_*)
+
+Examples:
+  ssm config resolve prod-db
+  ssm config resolve deploy@web1.corp.example.com`,
+	Args: cobra.ExactArgs(1),
+	Run:  runConfigResolve,
+}
+
+var configPatternCmd = &cobra.Command{
+	Use:   "pattern",
+	Short: "Manage pattern defaults for matching hosts",
+	Long: `Manage pattern entries. A pattern is a glob (* and ?, matched against the host
+name, case-insensitive) or a CIDR (matched against IP addresses). Hosts that
+match a pattern inherit its user, port, key, certificate, jump hosts, agent
+forwarding and algorithms when they do not set them themselves. Inherited
+values are used for the connection but not saved with the host.
+
+Examples:
+  ssm config pattern set '*.corp.example.com' --user deploy -J bastion
+  ssm config pattern set 10.0.0.0/8 -i ~/.ssh/internal_key
+  ssm config pattern list
+  ssm config pattern rm '*.corp.example.com'`,
+}
+
+var configPatternSetCmd = &cobra.Command{
+	Use:   "set pattern",
+	Short: "Create or update a pattern",
+	Args:  cobra.ExactArgs(1),
+	Run:   runConfigPatternSet,
+}
+
+var configPatternRmCmd = &cobra.Command{
+	Use:     "rm pattern",
+	Aliases: []string{"remove"},
+	Short:   "Remove a pattern",
+	Args:    cobra.ExactArgs(1),
+	Run:     runConfigPatternRm,
+}
+
+var configPatternListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List patterns, highest precedence first",
+	Args:    cobra.NoArgs,
+	Run:     runConfigPatternList,
+}
+
+func init() {
+	configPatternSetCmd.Flags().StringP("user", "u", "", "Default username")
+	configPatternSetCmd.Flags().StringP("port", "p", "", "Default port")
+	configPatternSetCmd.Flags().StringP("identity", "i", "", "Private key file path")
+	configPatternSetCmd.Flags().String("certificate", "", "OpenSSH user certificate file path")
+	configPatternSetCmd.Flags().StringP("proxy-jump", "J", "", "Jump hosts. Format: [user@]hostname[:port][,...] or alias, 'none' to clear")
+	configPatternSetCmd.Flags().Bool("identities-only", false, "Only use the configured identity for authentication")
+	configPatternSetCmd.Flags().BoolP("forward-agent", "A", false, "Forward the local SSH agent")
+	addAlgorithmFlags(configPatternSetCmd)
+
+	configPatternCmd.AddCommand(configPatternSetCmd)
+	configPatternCmd.AddCommand(configPatternRmCmd)
+	configPatternCmd.AddCommand(configPatternListCmd)
+
+	configAddCmd.Flags().Bool("password", false, "Prompt for a password and save it (in the vault if initialized)")
+	configSetCmd.Flags().Bool("password", false, "Prompt for a password and save it (in the vault if initialized)")
+
+	configCmd.AddCommand(configAddCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configRenameCmd)
+	configCmd.AddCommand(configCopyCmd)
+	configCmd.AddCommand(configResolveCmd)
+	configCmd.AddCommand(configPatternCmd)
+
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigAdd(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	if config.IsPattern(args[0]) {
+		fmt.Fprintln(os.Stderr, "Error: use 'ssm config pattern set' to add a pattern")
+		os.Exit(1)
+	}
+
+	username, hostname, port, _ := config.ResolveAddress(a.store, args[0])
+	sshConfig := &config.SSHConfig{Host: hostname, Username: username, Port: port}
+	if err := validateAddress(sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if _, exists := a.store.Get(sshConfig.GetKey()); exists {
+		fmt.Fprintf(os.Stderr, "Error: %s already exists, use 'ssm config set' to change it\n", sshConfig.GetKey())
+		os.Exit(1)
+	}
+
+	if err := editConfig(cmd, sshConfig, args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := a.saveConfig("", sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Added %s\n", sshConfig.GetKey())
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	sshConfig, err := a.lookupConfig(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(args) == 1 && !cmd.Flags().Changed("password") {
+		fmt.Fprintln(os.Stderr, "Error: nothing to set, give field=value pairs or --password")
+		os.Exit(1)
+	}
+
+	key := sshConfig.GetKey()
+	if err := editConfig(cmd, sshConfig, args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := a.saveConfig(key, sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if sshConfig.GetKey() != key {
+		fmt.Printf("Updated %s (renamed from %s)\n", sshConfig.GetKey(), key)
+		return
+	}
+	fmt.Printf("Updated %s\n", key)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	sshConfig, err := a.lookupConfig(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) == 2 {
+		if _, ok := sshConfig.Field(args[1]); !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown field %q (available: %s)\n", args[1], strings.Join(config.FieldNames(), ", "))
+			os.Exit(1)
+		}
+		fmt.Println(configFieldString(sshConfig, args[1]))
+		return
+	}
+
+	for _, name := range config.FieldNames() {
+		if value := configFieldString(sshConfig, name); value != "" {
+			fmt.Printf("%-20s %s\n", name, value)
+		}
+	}
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	sshConfig, err := a.lookupConfig(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, name := range args[1:] {
+		if name == "password" || name == "password_ref" {
+			err = a.auth.ForgetPassword(sshConfig)
+		} else {
+			err = sshConfig.UnsetField(name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := a.store.Put(sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Updated %s\n", sshConfig.GetKey())
+}
+
+func runConfigRename(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	sshConfig, err := a.lookupConfig(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	key := sshConfig.GetKey()
+	if err := retarget(sshConfig, args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := a.saveConfig(key, sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Renamed %s to %s\n", key, sshConfig.GetKey())
+}
+
+func runConfigCopy(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	sshConfig, err := a.lookupConfig(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	key := sshConfig.GetKey()
+	if err := retarget(sshConfig, args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	sshConfig.Alias = ""
+	sshConfig.LastUsed = ""
+	if err := a.auth.CopyPassword(sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to copy the saved password: %v\n", err)
+		os.Exit(1)
+	}
+	if err := a.saveConfig("", sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Copied %s to %s\n", key, sshConfig.GetKey())
+}
+
+// lookupConfig 按别名、模式或 [user@]hostname[:port] 查找已保存的配置
+func (a *app) lookupConfig(host string) (*config.SSHConfig, error) {
+	key := host
+	if !config.IsPattern(host) {
+		username, hostname, port := a.resolveHostArg(host)
+		key = utils.GetConfigKey(username, hostname, port)
+	}
+	sshConfig, exists := a.store.Get(key)
+	if !exists {
+		return nil, fmt.Errorf("connection config not found: %s", host)
+	}
+	return sshConfig, nil
+}
+
+// editConfig 按 field=value 参数修改配置：密钥和证书文件必须存在，算法列表必须能解析；
+// 指定 --password 时提示输入密码
+func editConfig(cmd *cobra.Command, sshConfig *config.SSHConfig, assignments []string) error {
+	a := appFrom(cmd)
+	for _, assignment := range assignments {
+		name, value, found := strings.Cut(assignment, "=")
+		if !found {
+			return fmt.Errorf("invalid argument %q: use field=value", assignment)
+		}
+
+		switch name {
+		case "password", "password_ref":
+			return fmt.Errorf("passwords cannot be given on the command line, use --password")
+		case "private_key", "certificate":
+			path, err := checkKeyFile(value)
+			if err != nil {
+				return err
+			}
+			value = path
+		}
+		if err := sshConfig.SetField(name, value); err != nil {
+			return err
+		}
+	}
+	if err := auth.ValidateAlgorithms(sshConfig); err != nil {
+		return err
+	}
+
+	if password, _ := cmd.Flags().GetBool("password"); password {
+		value, err := auth.PromptPassword(fmt.Sprintf("Password for %s: ", sshConfig.GetKey()))
+		if err != nil {
+			return fmt.Errorf("failed to read password: %v", err)
+		}
+		if value == "" {
+			return fmt.Errorf("empty password, use 'ssm config unset' to clear it")
+		}
+		sshConfig.Password = value
+		if err := a.auth.SealPassword(sshConfig); err != nil {
+			return fmt.Errorf("failed to save password to vault: %v", err)
+		}
+	}
+	return nil
+}
+
+// checkKeyFile 检查私钥或证书文件存在且是普通文件，返回展开 ~ 后的绝对路径
+func checkKeyFile(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("key file %s: %v", path, errors.Unwrap(err))
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("key file %s is not a regular file", path)
+	}
+	return path, nil
+}
+
+// retarget 修改配置的用户、主机名和端口，address 中未指定的部分保持不变
+func retarget(sshConfig *config.SSHConfig, address string) error {
+	if sshConfig.IsPattern() {
+		return sshConfig.SetField("host", address)
+	}
+
+	username, hostname, port := utils.SplitSSHHost(address)
+	if username == "" && hostname == "" && port == "" {
+		return fmt.Errorf("invalid address %q: use [user@][hostname][:port]", address)
+	}
+	for _, field := range [][2]string{{"username", username}, {"host", hostname}, {"port", port}} {
+		if field[1] == "" {
+			continue
+		}
+		if err := sshConfig.SetField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAddress 检查新配置的用户、主机名和端口
+func validateAddress(sshConfig *config.SSHConfig) error {
+	for _, name := range []string{"username", "host", "port"} {
+		if err := sshConfig.SetField(name, sshConfig.FieldString(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveConfig 保存配置。key 为修改前的配置键（新配置为空），配置键变化时移动到新键，新键已存在时返回错误；
+// 移动后显示跳板机链随之更新的配置
+func (a *app) saveConfig(key string, sshConfig *config.SSHConfig) error {
+	newKey := sshConfig.GetKey()
+	if newKey == key {
+		return a.store.Put(sshConfig)
+	}
+	if _, exists := a.store.Get(newKey); exists {
+		return fmt.Errorf("%s already exists", newKey)
+	}
+	if key == "" {
+		return a.store.Put(sshConfig)
+	}
+
+	updated, err := a.store.Move(key, sshConfig)
+	if err != nil {
+		return err
+	}
+	for _, updatedKey := range updated {
+		fmt.Printf("Updated jump host of %s to %s\n", updatedKey, newKey)
+	}
+	return nil
+}
+
+// configFieldString 字段的显示值，密码显示为 ********
+func configFieldString(sshConfig *config.SSHConfig, name string) string {
+	value := sshConfig.FieldString(name)
+	if name == "password" && value != "" {
+		return "********"
+	}
+	return value
+}
+
+func runConfigResolve(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	resolution := config.Resolve(a.store, args[0])
+	cfg := resolution.Config
+
+	status := "not saved"
+	if resolution.Saved {
+		status = "saved"
+	}
+	fmt.Printf("%s (%s)\n", cfg.GetKey(), status)
+	for _, name := range config.FieldNames() {
+		value := configFieldString(cfg, name)
+		if value == "" {
+			continue
+		}
+		fmt.Printf("  %-20s %-40s %s\n", name, value, resolution.Origins[name])
+	}
+
+	if len(resolution.Patterns) == 0 {
+		fmt.Println("No matching patterns.")
+		return
+	}
+	fmt.Println("Matching patterns, highest precedence first:")
+	for _, pattern := range resolution.Patterns {
+		fmt.Printf("  %s\n", pattern.Host)
+	}
+}
+
+func runConfigPatternSet(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	pattern := args[0]
+	if err := config.ValidatePattern(pattern); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sshConfig, exists := a.store.Get(pattern)
+	if !exists {
+		sshConfig = &config.SSHConfig{Host: pattern}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("user") {
+		sshConfig.Username, _ = flags.GetString("user")
+	}
+	if flags.Changed("port") {
+		port, _ := flags.GetString("port")
+		if port != "" {
+			if err := config.ValidatePort(port); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		sshConfig.Port = port
+	}
+	if flags.Changed("identity") {
+		sshConfig.PrivateKey, _ = flags.GetString("identity")
+	}
+	if flags.Changed("certificate") {
+		sshConfig.Certificate, _ = flags.GetString("certificate")
+	}
+	if flags.Changed("identities-only") {
+		sshConfig.IdentitiesOnly, _ = flags.GetBool("identities-only")
+	}
+	if flags.Changed("forward-agent") {
+		sshConfig.ForwardAgent, _ = flags.GetBool("forward-agent")
+	}
+	proxyJump, _ := flags.GetString("proxy-jump")
+	applyProxyJump(sshConfig, proxyJump)
+	applyAlgorithmFlags(cmd, sshConfig)
+
+	if err := a.store.Put(sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Pattern %s saved\n", pattern)
+}
+
+func runConfigPatternRm(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	if !config.IsPattern(args[0]) {
+		fmt.Fprintf(os.Stderr, "Error: %s is not a pattern\n", args[0])
+		os.Exit(1)
+	}
+	if err := a.store.Delete(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed pattern %s\n", args[0])
+}
+
+func runConfigPatternList(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	configs, err := a.store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configs: %v\n", err)
+		os.Exit(1)
+	}
+
+	_, patterns := config.SplitPatterns(configs)
+	if len(patterns) == 0 {
+		fmt.Println("No patterns found.")
+		return
+	}
+	config.SortPatterns(patterns)
+	for _, pattern := range patterns {
+		fmt.Printf("%-30s %s\n", pattern.Host, describePattern(&pattern))
+	}
+}
+
+// describePattern 模式设置的字段，如 "username=deploy proxy_jump=bastion"
+func describePattern(pattern *config.SSHConfig) string {
+	var fields []string
+	for _, name := range config.FieldNames() {
+		if name == "host" || name == "last_used" {
+			continue
+		}
+		if value := pattern.FieldString(name); value != "" {
+			fields = append(fields, name+"="+value)
+		}
+	}
+	if len(fields) == 0 {
+		return "(no settings)"
+	}
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,89 @@
+// cmd/config_test.go
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+func TestRenameConfig(t *testing.T) {
+	a := newTestApp(t,
+		config.SSHConfig{Alias: "prod", Host: "10.0.0.1", Username: "deploy", Port: "22", Notes: "db"},
+		config.SSHConfig{Host: "10.0.0.2", Username: "deploy", Port: "22"},
+	)
+
+	sshConfig, err := a.lookupConfig("prod")
+	if err != nil {
+		t.Fatalf("lookupConfig() error = %v", err)
+	}
+	if err := retarget(sshConfig, "10.0.0.9"); err != nil {
+		t.Fatalf("retarget() error = %v", err)
+	}
+	if err := a.saveConfig("deploy@10.0.0.1:22", sshConfig); err != nil {
+		t.Fatalf("saveConfig() error = %v", err)
+	}
+
+	if _, exists := a.store.Get("deploy@10.0.0.1:22"); exists {
+		t.Error("old key still exists after rename")
+	}
+	renamed, exists := config.GetByAlias(a.store, "prod")
+	if !exists || renamed.GetKey() != "deploy@10.0.0.9:22" || renamed.Notes != "db" {
+		t.Errorf("renamed config = %+v, want deploy@10.0.0.9:22 with alias and notes kept", renamed)
+	}
+
+	// 新键已存在时不修改
+	if err := retarget(renamed, "10.0.0.2"); err != nil {
+		t.Fatalf("retarget() error = %v", err)
+	}
+	if err := a.saveConfig("deploy@10.0.0.9:22", renamed); err == nil {
+		t.Error("saveConfig() onto an existing key expected an error")
+	}
+	if _, exists := a.store.Get("deploy@10.0.0.9:22"); !exists {
+		t.Error("config was removed by a failed rename")
+	}
+}
+
+func TestRetarget(t *testing.T) {
+	tests := map[string]string{
+		":2222":         "root@10.0.0.1:2222",
+		"admin@":        "admin@10.0.0.1:22",
+		"web2":          "root@web2:22",
+		"deploy@web3:2": "deploy@web3:2",
+	}
+	for address, want := range tests {
+		sshConfig := &config.SSHConfig{Host: "10.0.0.1", Username: "root", Port: "22"}
+		if err := retarget(sshConfig, address); err != nil {
+			t.Errorf("retarget(%q) error = %v", address, err)
+			continue
+		}
+		if got := sshConfig.GetKey(); got != want {
+			t.Errorf("retarget(%q) = %s, want %s", address, got, want)
+		}
+	}
+
+	for _, address := range []string{"", ":0", "@"} {
+		if err := retarget(&config.SSHConfig{Host: "10.0.0.1", Username: "root", Port: "22"}, address); err == nil {
+			t.Errorf("retarget(%q) expected an error", address)
+		}
+	}
+}
+
+func TestCheckKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_test")
+	if err := os.WriteFile(key, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if path, err := checkKeyFile(key); err != nil || path != key {
+		t.Errorf("checkKeyFile(%s) = %s, %v", key, path, err)
+	}
+	for _, path := range []string{filepath.Join(dir, "missing"), dir} {
+		if _, err := checkKeyFile(path); err == nil {
+			t.Errorf("checkKeyFile(%s) expected an error", path)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// cmd/copyid.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/wuxs/ssm/pkg/auth"
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/jump"
+	"github.com/wuxs/ssm/pkg/sftp"
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+var copyIDCmd = &cobra.Command{
+	Use:   "copy-id [flags] [user@]hostname[:port]",
+	Short: "Install your public key on a remote server and switch the host to key auth",
+	Long: `Install your public key into ~/.ssh/authorized_keys on a remote server.
+
+The connection uses the normal ssm connect path (saved configs, jump hosts,
+password or keyboard-interactive auth). The key is only appended if it is not
+already present, and ~/.ssh and authorized_keys get safe permissions.
+After verifying that the key works, the stored connection config is switched
+to the key and its saved password is removed.
+
+Examples:
+  ssm copy-id user@hostname                        # Install the default key
+  ssm copy-id -i ~/.ssh/work user@hostname:2222    # Install a specific key
+  ssm copy-id -J jumphost user@target              # Install through a jump host`,
+	Args: cobra.ExactArgs(1),
+	Run:  runCopyIDCommand,
+}
+
+func init() {
+	copyIDCmd.Flags().StringP("identity", "i", "", "Private key whose public key (<key>.pub) is installed (default is the first key found in ~/.ssh)")
+	copyIDCmd.Flags().StringP("proxy-jump", "J", "", "Connect via jump hosts, saved per host. Format: [user@]hostname[:port][,...] or alias, 'none' to clear")
+
+	rootCmd.AddCommand(copyIDCmd)
+}
+
+func runCopyIDCommand(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	username, hostname, port := a.resolveHostArg(args[0])
+	privateKeyPath, _ := cmd.Flags().GetString("identity")
+	proxyJump, _ := cmd.Flags().GetString("proxy-jump")
+
+	if privateKeyPath == "" {
+		privateKeyPath = findDefaultIdentity()
+		if privateKeyPath == "" {
+			fmt.Fprintf(os.Stderr, "Error: No key found in ~/.ssh, run 'ssm keygen' first or use -i\n")
+			os.Exit(1)
+		}
+	}
+
+	authorizedKey, err := readAuthorizedKeyLine(privateKeyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 使用已保存的配置连接（密码、跳板机等）
+	key := utils.GetConfigKey(username, hostname, port)
+	sshConfig, exists := a.store.Get(key)
+	if !exists {
+		sshConfig = &config.SSHConfig{
+			Host:     hostname,
+			Username: username,
+			Port:     port,
+		}
+	}
+	config.ApplyPatterns(a.store, sshConfig)
+	applyProxyJump(sshConfig, proxyJump)
+
+	clientConfig, err := a.auth.CreateClientConfig(sshConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create SSH config: %v\n", err)
+		os.Exit(1)
+	}
+
+	client, err := jump.Dial(a.store, a.auth, sshConfig, clientConfig, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+
+	added, err := sftp.InstallAuthorizedKey(client, authorizedKey)
+	client.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to install public key: %v\n", err)
+		os.Exit(1)
+	}
+
+	if added {
+		fmt.Printf("Public key %s.pub added to %s\n", privateKeyPath, sshConfig.GetKey())
+	} else {
+		fmt.Printf("Public key %s.pub is already installed on %s\n", privateKeyPath, sshConfig.GetKey())
+	}
+
+	// 确认密钥可以登录后再切换配置，避免清除唯一可用的密码
+	if err := a.verifyKeyLogin(sshConfig, privateKeyPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Key authentication failed, stored config left unchanged: %v\n", err)
+		os.Exit(1)
+	}
+
+	sshConfig.PrivateKey = privateKeyPath
+	if err := a.auth.ForgetPassword(sshConfig); err != nil {
+		fmt.Printf("Warning: Failed to remove password from vault: %v\n", err)
+	}
+	sshConfig.UpdateLastUsed()
+	if err := a.store.Put(sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save config: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Key authentication verified, %s now uses %s and no saved password.\n", sshConfig.GetKey(), privateKeyPath)
+}
+
+// findDefaultIdentity 查找第一个带有公钥文件的默认私钥，优先 ed25519
+func findDefaultIdentity() string {
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		keyPath := filepath.Join(os.Getenv("HOME"), ".ssh", name)
+		if _, err := os.Stat(keyPath + ".pub"); err == nil {
+			return keyPath
+		}
+	}
+	return ""
+}
+
+// readAuthorizedKeyLine 读取要安装的公钥（保留注释），没有 .pub 文件时从私钥导出
+func readAuthorizedKeyLine(privateKeyPath string) (string, error) {
+	if data, err := os.ReadFile(privateKeyPath + ".pub"); err == nil {
+		publicKey, comment, _, _, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse public key %s.pub: %v", privateKeyPath, err)
+		}
+		return auth.FormatAuthorizedKey(publicKey, comment), nil
+	}
+
+	signer, err := auth.LoadPrivateKey(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	return auth.FormatAuthorizedKey(signer.PublicKey(), ""), nil
+}
+
+// verifyKeyLogin 只使用指定私钥重新登录，确认公钥认证可用
+func (a *app) verifyKeyLogin(cfg *config.SSHConfig, privateKeyPath string) error {
+	signer, err := auth.LoadPrivateKey(privateKeyPath)
+	if err != nil {
+		return err
+	}
+
+	verifyConfig := *cfg
+	verifyConfig.PrivateKey = privateKeyPath
+
+	clientConfig, err := a.auth.CreatePublicKeyClientConfig(&verifyConfig, signer)
+	if err != nil {
+		return err
+	}
+
+	client, err := jump.Dial(a.store, a.auth, &verifyConfig, clientConfig, os.Stdout)
+	if err != nil {
+		return err
+	}
+	return client.Close()
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/history"
 	"github.com/wuxs/ssm/pkg/sftp"
 	"github.com/wuxs/ssm/pkg/utils"
 )
@@ -20,11 +21,12 @@ Supports jump hosts and recursive directory copying.
 
 The source and destination can be:
 - Local file/directory: /path/to/file
-- Remote file/directory: [user@]hostname[:port]:/path/to/file
+- Remote file/directory: [user@]hostname[:port]:/path/to/file or alias:/path/to/file
 
 Examples:
   ssm cp file.txt user@host:/remote/path/          # Copy local file to remote
   ssm cp user@host:/remote/file.txt ./             # Copy remote file to local
+  ssm cp backup.sql prod-db:/tmp/                  # Copy to a host by alias
   ssm cp -r local-dir user@host:/remote/           # Copy directory recursively
   ssm cp -J jumphost file.txt user@target:/path/  # Copy through jump host
   ssm cp -J jump.example.com user@host:/file ./   # Download through jump host`,
@@ -35,15 +37,19 @@ Examples:
 func init() {
 	cpCmd.Flags().StringP("identity", "i", "", "Private key file for authentication")
 	cpCmd.Flags().StringP("port", "p", "", "Port to connect to on the remote host")
-	cpCmd.Flags().StringP("proxy-jump", "J", "", "Connect via jump host. Format: [user@]hostname[:port]")
+	cpCmd.Flags().StringP("proxy-jump", "J", "", "Connect via jump hosts, saved per host. Format: [user@]hostname[:port][,...] or alias, 'none' to clear")
+	cpCmd.Flags().String("certificate", "", "OpenSSH user certificate to use with the private key")
+	cpCmd.Flags().Bool("identities-only", false, "Only offer the identity given by -i (from ssh-agent or key file)")
 	cpCmd.Flags().BoolP("recursive", "r", false, "Copy directories recursively")
 	cpCmd.Flags().BoolP("verbose", "v", false, "Show verbose output")
 	cpCmd.Flags().Bool("preserve", false, "Preserve file modes and timestamps")
+	addAlgorithmFlags(cpCmd)
 
 	rootCmd.AddCommand(cpCmd)
 }
 
 func runCopyCommand(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
 	source := args[0]
 	destination := args[1]
 
@@ -54,17 +60,21 @@ func runCopyCommand(cmd *cobra.Command, args []string) {
 	recursive, _ := cmd.Flags().GetBool("recursive")
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	preserve, _ := cmd.Flags().GetBool("preserve")
-
-	privateKeyPath = utils.GetDefaultPrivateKeyPath(privateKeyPath)
+	certificatePath, _ := cmd.Flags().GetString("certificate")
+	var identitiesOnly *bool
+	if cmd.Flags().Changed("identities-only") {
+		value, _ := cmd.Flags().GetBool("identities-only")
+		identitiesOnly = &value
+	}
 
 	// 解析源和目标
-	srcLocation, err := parseLocation(source, portFlag)
+	srcLocation, err := a.parseLocation(source, portFlag)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing source: %v\n", err)
 		os.Exit(1)
 	}
 
-	dstLocation, err := parseLocation(destination, portFlag)
+	dstLocation, err := a.parseLocation(destination, portFlag)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing destination: %v\n", err)
 		os.Exit(1)
@@ -94,7 +104,8 @@ func runCopyCommand(cmd *cobra.Command, args []string) {
 	}
 
 	// 创建SSH配置
-	sshConfig := createSSHConfigForLocation(remoteLocation, privateKeyPath, proxyJump)
+	sshConfig := a.createSSHConfigForLocation(remoteLocation, privateKeyPath, certificatePath, proxyJump, identitiesOnly)
+	applyAlgorithmFlags(cmd, sshConfig)
 
 	// 创建传输选项
 	options := &sftp.TransferOptions{
@@ -103,9 +114,26 @@ func runCopyCommand(cmd *cobra.Command, args []string) {
 		Preserve:  preserve,
 	}
 
-	// 执行文件传输
-	transferManager := sftp.NewTransferManager()
-	if err := transferManager.Transfer(sshConfig, srcLocation, dstLocation, options); err != nil {
+	// 执行文件传输，结束后记录连接历史和传输的字节数
+	entry := newHistoryEntry(history.KindCp, sshConfig)
+	transferManager := sftp.NewTransferManager(a.store, a.auth)
+	err = transferManager.Transfer(sshConfig, srcLocation, dstLocation, options)
+
+	direction := "upload"
+	if srcLocation.IsRemoteLocation() {
+		direction = "download"
+	}
+	files, bytes := transferManager.Stats()
+	entry.Transfer = &history.Transfer{
+		Direction:   direction,
+		Source:      source,
+		Destination: destination,
+		Files:       files,
+		Bytes:       bytes,
+	}
+	exitStatus, _ := exitStatusOf(err)
+	a.recordHistory(entry, exitStatus, err)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Transfer failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -142,7 +170,7 @@ type LocationInterface interface {
 }
 
 // parseLocation 解析位置字符串
-func parseLocation(location, defaultPort string) (LocationInterface, error) {
+func (a *app) parseLocation(location, defaultPort string) (LocationInterface, error) {
 	// 检查是否是远程位置 (包含 :)
 	if !strings.Contains(location, ":") {
 		// 本地位置
@@ -168,8 +196,8 @@ func parseLocation(location, defaultPort string) (LocationInterface, error) {
 		}, nil
 	}
 
-	// 解析远程主机信息
-	username, hostname, port := utils.ParseSSHHost(hostPart)
+	// 解析远程主机信息（优先匹配别名）
+	username, hostname, port := a.resolveHostArg(hostPart)
 	if defaultPort != "" {
 		port = defaultPort
 	}
@@ -182,27 +210,32 @@ func parseLocation(location, defaultPort string) (LocationInterface, error) {
 	}, nil
 }
 
-// createSSHConfigForLocation 为位置创建SSH配置
-func createSSHConfigForLocation(location *RemoteLocationInfo, privateKeyPath, proxyJump string) *config.SSHConfig {
-	// 尝试从现有配置中获取
+// createSSHConfigForLocation 为位置创建SSH配置，未设置的字段使用匹配模式的设置，命令行参数优先。
+// identitiesOnly 为 nil 表示未指定 --identities-only，保留已保存的设置
+func (a *app) createSSHConfigForLocation(location *RemoteLocationInfo, privateKeyPath, certificatePath, proxyJump string, identitiesOnly *bool) *config.SSHConfig {
+	// 尝试从现有配置中获取，没有时创建新配置
 	key := utils.GetConfigKey(location.Username, location.Hostname, location.Port)
-	if sshConfig, exists := config.Get(key); exists {
-		// 更新配置
-		if privateKeyPath != "" {
-			sshConfig.PrivateKey = privateKeyPath
-		}
-		if proxyJump != "" {
-			sshConfig.ProxyJump = proxyJump
+	sshConfig, exists := a.store.Get(key)
+	if !exists {
+		sshConfig = &config.SSHConfig{
+			Host:     location.Hostname,
+			Username: location.Username,
+			Port:     location.Port,
 		}
-		return sshConfig
 	}
+	config.ApplyPatterns(a.store, sshConfig)
 
-	// 创建新配置
-	return &config.SSHConfig{
-		Host:       location.Hostname,
-		Username:   location.Username,
-		Port:       location.Port,
-		PrivateKey: privateKeyPath,
-		ProxyJump:  proxyJump,
+	// 更新配置，未指定 -i 时不记录默认私钥
+	if privateKeyPath != "" {
+		sshConfig.PrivateKey = privateKeyPath
+	}
+	if certificatePath != "" {
+		sshConfig.Certificate = certificatePath
+	}
+	if identitiesOnly != nil {
+		sshConfig.IdentitiesOnly = *identitiesOnly
+		sshConfig.TrackOverride("identities_only")
 	}
+	applyProxyJump(sshConfig, proxyJump)
+	return sshConfig
 }
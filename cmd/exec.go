@@ -0,0 +1,254 @@
+// cmd/exec.go
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/history"
+	"github.com/wuxs/ssm/pkg/jump"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [--tag tag]... [host...] [--] command [args...]",
+	Short: "Run a command on one or more hosts",
+	Long: `Run a command on one or more hosts, selected by alias, [user@]hostname[:port]
+or tag. With several hosts every output line is prefixed with the host name, and
+the exit status is non-zero when the command failed on any host. Put the command
+after -- when it has options of its own.
+
+Examples:
+  ssm exec prod-db -- df -h                  # Run a command on one host
+  ssm exec web1 web2 -- uptime               # Run a command on several hosts
+  ssm exec --tag web -- systemctl status nginx
+  ssm exec --tag prod --tag web -P 10 uptime # Hosts with both tags, 10 at a time
+  ssm exec --tag web -- ls -la /var/log      # Use -- when the command has options`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runExecCommand,
+}
+
+func init() {
+	execCmd.Flags().StringSlice("tag", []string{}, "Run on saved hosts that have all of these tags")
+	execCmd.Flags().IntP("parallel", "P", 1, "Number of hosts to run the command on at the same time")
+
+	rootCmd.AddCommand(execCmd)
+}
+
+// execResult 单个主机的执行结果
+type execResult struct {
+	name       string
+	exitStatus int
+	err        error
+}
+
+func runExecCommand(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	parallel, _ := cmd.Flags().GetInt("parallel")
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	hostArgs, command := splitExecArgs(args, cmd.ArgsLenAtDash(), len(tags) > 0)
+	if len(command) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No command given")
+		os.Exit(1)
+	}
+
+	hosts, err := a.selectHosts(hostArgs, tags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	results := make([]execResult, len(hosts))
+	var outputMu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallel)
+	for i, host := range hosts {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			name := hostDisplayName(host)
+			stdout := &prefixWriter{mu: &outputMu, out: os.Stdout}
+			stderr := &prefixWriter{mu: &outputMu, out: os.Stderr}
+			if len(hosts) > 1 {
+				stdout.prefix = "[" + name + "] "
+				stderr.prefix = stdout.prefix
+			}
+
+			exitStatus, err := a.execOnHost(host, strings.Join(command, " "), stdout, stderr)
+			stdout.Flush()
+			stderr.Flush()
+			results[i] = execResult{name: name, exitStatus: exitStatus, err: err}
+		}()
+	}
+	wg.Wait()
+
+	os.Exit(reportExecResults(results))
+}
+
+// splitExecArgs 拆分主机和命令：有 -- 时之前为主机；没有 -- 时按标签选择主机则全部为命令，否则第一个参数为主机
+func splitExecArgs(args []string, dash int, hasTags bool) (hosts, command []string) {
+	switch {
+	case dash >= 0:
+		return args[:dash], args[dash:]
+	case hasTags:
+		return nil, args
+	default:
+		return args[:1], args[1:]
+	}
+}
+
+// selectHosts 按参数和标签选择主机，同一主机只出现一次；参数中的主机未保存时新建配置
+func (a *app) selectHosts(hostArgs, tags []string) ([]*config.SSHConfig, error) {
+	if len(hostArgs) == 0 && len(tags) == 0 {
+		return nil, fmt.Errorf("no hosts given, name hosts or use --tag")
+	}
+
+	var hosts []*config.SSHConfig
+	seen := make(map[string]bool)
+	add := func(cfg *config.SSHConfig) {
+		if !seen[cfg.GetKey()] {
+			seen[cfg.GetKey()] = true
+			hosts = append(hosts, cfg)
+		}
+	}
+
+	for _, host := range hostArgs {
+		add(a.getOrNewConfig(host))
+	}
+
+	if len(tags) > 0 {
+		configs, err := a.store.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load configs: %v", err)
+		}
+		hostConfigs, _ := config.SplitPatterns(configs)
+		tagged := config.FilterByTags(hostConfigs, tags)
+		if len(tagged) == 0 && len(hostArgs) == 0 {
+			return nil, fmt.Errorf("no hosts tagged %s", strings.Join(tags, ", "))
+		}
+		for i := range tagged {
+			add(&tagged[i])
+		}
+	}
+	return hosts, nil
+}
+
+// execOnHost 在主机上执行命令，返回远程命令的退出码，结束后记录连接历史
+func (a *app) execOnHost(cfg *config.SSHConfig, command string, stdout, stderr io.Writer) (exitStatus int, err error) {
+	config.ApplyPatterns(a.store, cfg)
+	entry := newHistoryEntry(history.KindExec, cfg)
+	entry.Command = command
+	defer func() { a.recordHistory(entry, exitStatus, err) }()
+
+	clientConfig, err := a.auth.CreateClientConfig(cfg)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create SSH config: %v", err)
+	}
+
+	client, err := jump.Dial(a.store, a.auth, cfg, clientConfig, stderr)
+	if err != nil {
+		return -1, fmt.Errorf("failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return -1, fmt.Errorf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	// 连接成功，更新并保存配置（密码只保存在保险库中）
+	if err := a.auth.SaveLogin(a.store, cfg); err != nil {
+		fmt.Fprintf(stderr, "Warning: %v\n", err)
+	}
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+	return exitStatusOf(session.Run(command))
+}
+
+// reportExecResults 显示执行失败的主机，返回本地进程的退出码：
+// 单个主机时与远程命令一致，多个主机时任一失败即为 1
+func reportExecResults(results []execResult) int {
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.err != nil:
+			fmt.Fprintf(os.Stderr, "%s: %v\n", result.name, result.err)
+			failed++
+		case result.exitStatus != 0:
+			if len(results) > 1 {
+				fmt.Fprintf(os.Stderr, "%s: exited with status %d\n", result.name, result.exitStatus)
+			}
+			failed++
+		}
+	}
+
+	if len(results) == 1 {
+		if results[0].err != nil {
+			return 255
+		}
+		return results[0].exitStatus
+	}
+	fmt.Fprintf(os.Stderr, "%d hosts, %d succeeded, %d failed\n", len(results), len(results)-failed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// hostDisplayName 主机显示名称，有别名时使用别名
+func hostDisplayName(cfg *config.SSHConfig) string {
+	if cfg.Alias != "" {
+		return cfg.Alias
+	}
+	return cfg.GetKey()
+}
+
+// prefixWriter 在每行输出前加上前缀，多个主机同时执行时按整行输出，不会交错在一行内
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush 输出最后不以换行结尾的内容
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+}
@@ -0,0 +1,78 @@
+// cmd/exec_test.go
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+func TestSplitExecArgs(t *testing.T) {
+	tests := []struct {
+		args           []string
+		dash           int
+		hasTags        bool
+		hosts, command []string
+	}{
+		{[]string{"web1", "web2", "uptime"}, 2, false, []string{"web1", "web2"}, []string{"uptime"}},
+		{[]string{"ls", "-la"}, 0, true, []string{}, []string{"ls", "-la"}},
+		{[]string{"uptime"}, -1, true, nil, []string{"uptime"}},
+		{[]string{"web1", "df", "-h"}, -1, false, []string{"web1"}, []string{"df", "-h"}},
+	}
+	for _, tt := range tests {
+		hosts, command := splitExecArgs(tt.args, tt.dash, tt.hasTags)
+		if !reflect.DeepEqual(hosts, tt.hosts) || !reflect.DeepEqual(command, tt.command) {
+			t.Errorf("splitExecArgs(%v, %d, %v) = %v, %v, want %v, %v",
+				tt.args, tt.dash, tt.hasTags, hosts, command, tt.hosts, tt.command)
+		}
+	}
+}
+
+func TestSelectHosts(t *testing.T) {
+	a := newTestApp(t,
+		config.SSHConfig{Alias: "web1", Host: "10.0.0.1", Username: "deploy", Port: "22", Tags: []string{"prod", "web"}},
+		config.SSHConfig{Alias: "web2", Host: "10.0.0.2", Username: "deploy", Port: "22", Tags: []string{"staging", "web"}},
+		config.SSHConfig{Alias: "db", Host: "10.0.0.3", Username: "deploy", Port: "22", Tags: []string{"prod"}},
+	)
+
+	names := func(hosts []*config.SSHConfig) []string {
+		var names []string
+		for _, host := range hosts {
+			names = append(names, hostDisplayName(host))
+		}
+		return names
+	}
+
+	hosts, err := a.selectHosts(nil, []string{"prod", "web"})
+	if err != nil || !reflect.DeepEqual(names(hosts), []string{"web1"}) {
+		t.Errorf("selectHosts(--tag prod --tag web) = %v, %v", names(hosts), err)
+	}
+
+	// 参数与标签选中同一主机时只执行一次
+	hosts, err = a.selectHosts([]string{"db", "root@10.0.0.9"}, []string{"prod"})
+	if err != nil || len(hosts) != 3 {
+		t.Errorf("selectHosts(db root@10.0.0.9 --tag prod) = %v, %v, want 3 hosts", names(hosts), err)
+	}
+
+	if _, err := a.selectHosts(nil, []string{"missing"}); err == nil {
+		t.Error("selectHosts() expected an error when no host has the tag")
+	}
+	if _, err := a.selectHosts(nil, nil); err == nil {
+		t.Error("selectHosts() expected an error without hosts or tags")
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := &prefixWriter{mu: &sync.Mutex{}, out: &out, prefix: "[web1] "}
+	w.Write([]byte("one\ntw"))
+	w.Write([]byte("o\nthree"))
+	w.Flush()
+
+	if want := "[web1] one\n[web1] two\n[web1] three\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,128 @@
+// cmd/export.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/sshconfig"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export connection configs as a bundle or for other tools",
+	Long: `Export connection configs. With --bundle the saved hosts and patterns are
+written to a single file that can be shared and read back with
+'ssm import --bundle'.
+
+Saved passwords are stripped from a bundle unless --secrets is given, which
+also embeds the private key and certificate files. --secrets requires
+--encrypt, which protects the whole bundle with a passphrase. Key paths under
+your home directory are written as ~/... so they work for other users.
+
+With --tag only hosts having all of the tags are exported, together with the
+jump hosts they go through and the patterns that match them.
+
+Examples:
+  ssm export --bundle team.json --tag prod               # Hosts only, no secrets
+  ssm export --bundle team.json --encrypt                # Passphrase protected
+  ssm export --bundle backup.json --encrypt --secrets    # Include passwords and keys
+  ssm export ssh-config                                  # OpenSSH client config`,
+	Args: cobra.NoArgs,
+	Run:  runExportBundle,
+}
+
+var exportSSHConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "Export the saved connections as an OpenSSH client config",
+	Long: `Render every saved connection as an OpenSSH Host block, so tools that read
+~/.ssh/config (VS Code Remote, ansible, plain ssh) can use them.
+
+Hosts are named after their hostname; when several connections share a
+hostname they are named user-host or user-host-port. Ports, IdentityFile,
+certificates, ProxyJump chains and algorithm settings are included. Saved
+passwords are never exported.
+
+With -o the output is written to a file managed by ssm. The output is sorted
+and stable, so the file is only rewritten when the connections changed, and a
+file not generated by ssm is never overwritten without --force. --include adds
+an Include line for the managed file to the top of ~/.ssh/config, keeping the
+previous version as ~/.ssh/config.bak.
+
+Examples:
+  ssm export ssh-config                                  # Print to stdout
+  ssm export ssh-config -o ~/.ssh/ssm.conf --include     # Managed file included from ~/.ssh/config`,
+	Args: cobra.NoArgs,
+	Run:  runExportSSHConfig,
+}
+
+func init() {
+	exportSSHConfigCmd.Flags().StringP("output", "o", "", "Write to a managed file instead of stdout")
+	exportSSHConfigCmd.Flags().Bool("include", false, "Add an Include line for the output file to ~/.ssh/config")
+	exportSSHConfigCmd.Flags().Bool("force", false, "Overwrite the output file even if it was not generated by ssm")
+
+	exportCmd.Flags().String("bundle", "", "Write the saved hosts to a bundle file")
+	exportCmd.Flags().StringSlice("tag", []string{}, "Only export hosts that have all of these tags")
+	exportCmd.Flags().Bool("encrypt", false, "Encrypt the bundle with a passphrase")
+	exportCmd.Flags().Bool("secrets", false, "Include saved passwords, private keys and certificates (requires --encrypt)")
+
+	exportCmd.AddCommand(exportSSHConfigCmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExportSSHConfig(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	output, _ := cmd.Flags().GetString("output")
+	include, _ := cmd.Flags().GetBool("include")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if include && output == "" {
+		fmt.Fprintf(os.Stderr, "Error: --include requires -o/--output\n")
+		os.Exit(1)
+	}
+
+	configs, err := a.store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configs: %v\n", err)
+		os.Exit(1)
+	}
+	configs, _ = config.SplitPatterns(configs)
+	content := sshconfig.Render(configs)
+
+	if output == "" {
+		fmt.Print(content)
+		return
+	}
+
+	changed, err := sshconfig.WriteManaged(output, content, force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if changed {
+		fmt.Printf("Exported %d hosts to %s\n", len(configs), output)
+	} else {
+		fmt.Printf("%s is up to date\n", output)
+	}
+
+	if include {
+		// OpenSSH 相对 ~/.ssh 解析 Include 路径，写入绝对路径避免歧义
+		includePath, err := filepath.Abs(output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		added, err := sshconfig.EnsureInclude(sshconfig.GetDefaultPath(), includePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if added {
+			fmt.Printf("Added \"Include %s\" to %s\n", includePath, sshconfig.GetDefaultPath())
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// cmd/group.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Manage host groups",
+	Long: `Manage host groups. Every host belongs to at most one group, and
+'ssm --list' shows hosts grouped by it.
+
+Examples:
+  ssm group set prod-db databases    # Put a host into a group
+  ssm group rm prod-db               # Remove a host from its group`,
+}
+
+var groupSetCmd = &cobra.Command{
+	Use:   "set host group",
+	Short: "Set the group of a host (the connection is created if needed)",
+	Args:  cobra.ExactArgs(2),
+	Run:   runGroupSet,
+}
+
+var groupRmCmd = &cobra.Command{
+	Use:     "rm host",
+	Aliases: []string{"remove"},
+	Short:   "Remove a host from its group",
+	Args:    cobra.ExactArgs(1),
+	Run:     runGroupRm,
+}
+
+func init() {
+	groupCmd.AddCommand(groupSetCmd)
+	groupCmd.AddCommand(groupRmCmd)
+
+	rootCmd.AddCommand(groupCmd)
+}
+
+func runGroupSet(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	group := args[1]
+	if err := config.ValidateGroup(group); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sshConfig := a.getOrNewConfig(args[0])
+	sshConfig.Group = group
+	if err := a.store.Put(sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s is in group %s\n", sshConfig.GetKey(), group)
+}
+
+func runGroupRm(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	username, hostname, port := a.resolveHostArg(args[0])
+	key := utils.GetConfigKey(username, hostname, port)
+	sshConfig, exists := a.store.Get(key)
+	if !exists || sshConfig.Group == "" {
+		fmt.Fprintf(os.Stderr, "Error: %s is not in a group\n", key)
+		os.Exit(1)
+	}
+
+	group := sshConfig.Group
+	sshConfig.Group = ""
+	if err := a.store.Put(sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed %s from group %s\n", key, group)
+}
@@ -0,0 +1,306 @@
+// cmd/history.go
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/history"
+	"github.com/wuxs/ssm/pkg/sftp"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [host]",
+	Short: "Show the connection history",
+	Long: `Show the connection history: interactive sessions, ssm exec and ssm cp, with
+start time, duration, exit status, jump hosts, port forwards and transfer sizes.
+The host may be an alias, a config key, a host name or a host name glob.
+
+Times for --since and --until are a date (2006-01-02), a date and time
+(2006-01-02 15:04 or RFC 3339), or a duration back from now (90m, 12h, 7d).
+
+Examples:
+  ssm history                          # Last 50 connections
+  ssm history prod-db --since 7d       # Connections to prod-db in the last 7 days
+  ssm history '*.corp' --failed        # Failed connections to matching hosts
+  ssm history --kind cp --since 2026-01-01 --until 2026-02-01 -n 0
+  ssm history retention --max-age-days 30 --max-entries 1000
+  ssm history clear`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runHistory,
+}
+
+var historyRetentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Show or change how long the history is kept",
+	Long: `Show or change how long the history is kept. Entries older than --max-age-days
+and the oldest entries beyond --max-entries are no longer shown. They are removed
+from the file when a connection is recorded and the history is more than 10% over
+--max-entries or a day past --max-age-days, or right away with 'ssm history prune'.
+0 means no limit.`,
+	Args: cobra.NoArgs,
+	Run:  runHistoryRetention,
+}
+
+var historyPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove entries outside the retention settings now",
+	Args:  cobra.NoArgs,
+	Run:   runHistoryPrune,
+}
+
+var historyClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all history entries",
+	Args:  cobra.NoArgs,
+	Run:   runHistoryClear,
+}
+
+func init() {
+	historyCmd.Flags().String("since", "", "Only show connections started at or after this time")
+	historyCmd.Flags().String("until", "", "Only show connections started before this time (a date includes the whole day)")
+	historyCmd.Flags().String("kind", "", "Only show one kind of connection: ssh, exec or cp")
+	historyCmd.Flags().Bool("failed", false, "Only show failed connections and non-zero exit statuses")
+	historyCmd.Flags().IntP("limit", "n", 50, "Show at most this many of the latest entries, 0 for all")
+
+	historyRetentionCmd.Flags().Int("max-age-days", 0, "Remove entries older than this many days, 0 to keep them")
+	historyRetentionCmd.Flags().Int("max-entries", 0, "Keep at most this many entries, 0 for no limit")
+
+	historyCmd.AddCommand(historyRetentionCmd)
+	historyCmd.AddCommand(historyPruneCmd)
+	historyCmd.AddCommand(historyClearCmd)
+
+	rootCmd.AddCommand(historyCmd)
+}
+
+// newHistoryEntry 开始记录一次连接
+func newHistoryEntry(kind string, cfg *config.SSHConfig) *history.Entry {
+	return &history.Entry{
+		Kind:      kind,
+		Host:      cfg.GetKey(),
+		Alias:     cfg.Alias,
+		Start:     time.Now(),
+		ProxyJump: cfg.ProxyJump,
+	}
+}
+
+// recordHistory 结束并写入一条连接记录，写入失败只显示警告
+func (a *app) recordHistory(entry *history.Entry, exitStatus int, err error) {
+	if a.history == nil {
+		return
+	}
+	entry.Finish(exitStatus, err)
+	if err := a.history.Append(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to record history: %v\n", err)
+	}
+}
+
+// exitStatusOf 将会话结束的错误转换为退出码：远程命令退出（包括非零退出码）不算错误，
+// 其他错误的退出码为 -1
+func exitStatusOf(err error) (int, error) {
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
+
+// describeForwards 端口转发的历史记录格式，如 "L 8080:localhost:80"
+func describeForwards(localForwards, remoteForwards []string) []string {
+	var forwards []string
+	for _, forward := range localForwards {
+		forwards = append(forwards, "L "+forward)
+	}
+	for _, forward := range remoteForwards {
+		forwards = append(forwards, "R "+forward)
+	}
+	return forwards
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	var filter history.Filter
+	if len(args) > 0 {
+		filter.Host = args[0]
+		if sshConfig, exists := config.GetByAlias(a.store, args[0]); exists {
+			filter.Host = sshConfig.GetKey()
+		}
+	}
+
+	var err error
+	now := time.Now()
+	if since, _ := cmd.Flags().GetString("since"); since != "" {
+		if filter.Since, err = parseHistoryTime(since, now, false); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if until, _ := cmd.Flags().GetString("until"); until != "" {
+		if filter.Until, err = parseHistoryTime(until, now, true); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	filter.Kind, _ = cmd.Flags().GetString("kind")
+	switch filter.Kind {
+	case "", history.KindSSH, history.KindExec, history.KindCp:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid kind %q: use ssh, exec or cp\n", filter.Kind)
+		os.Exit(1)
+	}
+	filter.Failed, _ = cmd.Flags().GetBool("failed")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	entries, err := a.history.Read(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No history found.")
+		return
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	for i := range entries {
+		fmt.Println(formatHistoryEntry(&entries[i]))
+	}
+}
+
+func runHistoryRetention(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	retention, err := a.history.Retention()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	changed := false
+	if cmd.Flags().Changed("max-age-days") {
+		retention.MaxAgeDays, _ = cmd.Flags().GetInt("max-age-days")
+		changed = true
+	}
+	if cmd.Flags().Changed("max-entries") {
+		retention.MaxEntries, _ = cmd.Flags().GetInt("max-entries")
+		changed = true
+	}
+	if changed {
+		if err := a.history.SetRetention(retention); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Max age:     %s\n", retentionLimit(retention.MaxAgeDays, "day"))
+	fmt.Printf("Max entries: %s\n", retentionLimit(retention.MaxEntries, "entry"))
+}
+
+func runHistoryPrune(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	retention, err := a.history.Retention()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	removed, err := a.history.Prune(retention)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed %s.\n", pluralize(removed, "entry"))
+}
+
+func runHistoryClear(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	if err := a.history.Clear(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("History cleared.")
+}
+
+// retentionLimit 保留设置的显示值，0 为 unlimited
+func retentionLimit(count int, noun string) string {
+	if count == 0 {
+		return "unlimited"
+	}
+	return pluralize(count, noun)
+}
+
+// parseHistoryTime 解析时间：日期、日期时间或距今的时长（如 7d、12h）。
+// endOfDay 为 true 时只有日期的时间取第二天零点，使结束日期包含当天
+func parseHistoryTime(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use 2006-01-02, '2006-01-02 15:04', RFC 3339 or a duration such as 12h or 7d", value)
+}
+
+// formatHistoryEntry 历史记录的显示格式：开始时间、类型、主机、时长、结果和详情
+func formatHistoryEntry(entry *history.Entry) string {
+	host := entry.Host
+	if entry.Alias != "" {
+		host = fmt.Sprintf("%s (%s)", entry.Alias, entry.Host)
+	}
+
+	status := "ok"
+	switch {
+	case entry.Error != "":
+		status = "failed"
+	case entry.ExitStatus != 0:
+		status = fmt.Sprintf("exit %d", entry.ExitStatus)
+	}
+
+	duration := time.Duration(entry.Duration * float64(time.Second)).Round(time.Second)
+	line := fmt.Sprintf("%s  %-4s  %-40s %8s  %-7s", entry.Start.Local().Format("2006-01-02 15:04:05"), entry.Kind, host, duration, status)
+
+	var details []string
+	if entry.ProxyJump != "" {
+		details = append(details, "via "+entry.ProxyJump)
+	}
+	details = append(details, entry.Forwards...)
+	if entry.Command != "" {
+		details = append(details, entry.Command)
+	}
+	if transfer := entry.Transfer; transfer != nil {
+		details = append(details, fmt.Sprintf("%s %s -> %s (%s, %s)", transfer.Direction, transfer.Source, transfer.Destination,
+			pluralize(transfer.Files, "file"), sftp.FormatBytes(transfer.Bytes)))
+	}
+	if entry.Error != "" {
+		details = append(details, entry.Error)
+	}
+	if len(details) > 0 {
+		line += "  " + strings.Join(details, ", ")
+	}
+	return line
+}
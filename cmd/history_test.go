@@ -0,0 +1,33 @@
+// cmd/history_test.go
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		value    string
+		endOfDay bool
+		want     time.Time
+	}{
+		{"7d", false, now.AddDate(0, 0, -7)},
+		{"90m", false, now.Add(-90 * time.Minute)},
+		{"2026-03-01", false, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)},
+		{"2026-03-01", true, time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)},
+		{"2026-03-01 08:30", true, time.Date(2026, 3, 1, 8, 30, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := parseHistoryTime(tt.value, now, tt.endOfDay)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseHistoryTime(%q, %v) = %v, %v, want %v", tt.value, tt.endOfDay, got, err, tt.want)
+		}
+	}
+
+	if _, err := parseHistoryTime("yesterday", now, false); err == nil {
+		t.Error("parseHistoryTime() expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,410 @@
+// cmd/import.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/auth"
+	"github.com/wuxs/ssm/pkg/bundle"
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/sshconfig"
+	"github.com/wuxs/ssm/pkg/vault"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import connection configs from a bundle or other tools",
+	Long: `Import connection configs. With --bundle the hosts and patterns of a file
+written by 'ssm export --bundle' are imported; encrypted bundles ask for their
+passphrase, and private keys embedded in the bundle are saved under ~/.ssm/keys
+unless the same file already exists at its original path.
+
+A preview of the changes is always shown first. Hosts whose user@host:port
+already exists in ssm are conflicts and are handled with --conflict:
+  skip       keep the existing config (default)
+  overwrite  replace it with the bundle's config
+  newest     replace it only if the bundle's config was used more recently
+             (compared by last used time)
+Saved passwords are kept when the bundle does not include secrets.
+
+Examples:
+  ssm import --bundle team.json                    # Preview and import
+  ssm import --bundle team.json --dry-run          # Only show what would change
+  ssm import --bundle backup.json --conflict newest -y
+  ssm import ssh-config                            # Import ~/.ssh/config`,
+	Args: cobra.NoArgs,
+	Run:  runImportBundle,
+}
+
+var importSSHConfigCmd = &cobra.Command{
+	Use:   "ssh-config [path]",
+	Short: "Import hosts from an OpenSSH client config (default ~/.ssh/config)",
+	Long: `Import Host blocks from an OpenSSH client config into ssm.
+
+HostName, User, Port, IdentityFile, CertificateFile, IdentitiesOnly,
+ForwardAgent, ProxyJump and the algorithm lists are imported. Include files
+are followed, and Host blocks with wildcard patterns (like "Host *") are not
+imported themselves but provide defaults for the hosts they match, with the
+same first-value-wins rule as ssh. Match blocks are skipped.
+
+A preview of the changes is always shown first. Hosts whose user@host:port
+already exists in ssm are conflicts and are handled with --conflict:
+  skip       keep the existing config (default)
+  overwrite  replace it with the imported settings (saved passwords are kept)
+  merge      only fill in settings missing from the existing config
+
+Examples:
+  ssm import ssh-config                          # Preview and import ~/.ssh/config
+  ssm import ssh-config --dry-run                # Only show what would change
+  ssm import ssh-config ~/work/ssh_config --conflict merge -y`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runImportSSHConfig,
+}
+
+func init() {
+	importSSHConfigCmd.Flags().Bool("dry-run", false, "Show the changes without writing them")
+	importSSHConfigCmd.Flags().String("conflict", "skip", "How to handle hosts that already exist: skip, overwrite or merge")
+	importSSHConfigCmd.Flags().BoolP("yes", "y", false, "Apply the changes without asking for confirmation")
+
+	importCmd.Flags().String("bundle", "", "Import hosts from a bundle file written by 'ssm export --bundle'")
+	importCmd.Flags().Bool("dry-run", false, "Show the changes without writing them")
+	importCmd.Flags().String("conflict", "skip", "How to handle hosts that already exist: skip, overwrite or newest")
+	importCmd.Flags().BoolP("yes", "y", false, "Apply the changes without asking for confirmation")
+
+	importCmd.AddCommand(importSSHConfigCmd)
+	rootCmd.AddCommand(importCmd)
+}
+
+// importChange 导入单个主机的变更
+type importChange struct {
+	alias    string
+	result   *config.SSHConfig // 导入后的配置
+	existing *config.SSHConfig // 已存在的配置，新主机为 nil
+	diffs    []string
+	action   string            // 与已有配置冲突时的处理，如 "overwrites existing config"
+	warning  string            // 导入时忽略的设置
+	note     string            // 无法导入的原因
+	apply    bool              // 是否写入
+	keys     map[string]string // 写入前需要保存的密钥文件：本地路径 -> 内容
+}
+
+func runImportSSHConfig(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	path := sshconfig.GetDefaultPath()
+	if len(args) > 0 {
+		path = args[0]
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	conflict, _ := cmd.Flags().GetString("conflict")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	if conflict != "skip" && conflict != "overwrite" && conflict != "merge" {
+		fmt.Fprintf(os.Stderr, "Error: Invalid --conflict %q, use skip, overwrite or merge\n", conflict)
+		os.Exit(1)
+	}
+
+	file, err := sshconfig.Parse(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, warning := range file.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	store, err := config.Snapshot(a.store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configs: %v\n", err)
+		os.Exit(1)
+	}
+
+	changes := planImport(file, store, conflict)
+	if len(changes) == 0 {
+		fmt.Printf("No hosts found in %s\n", file.Path)
+		return
+	}
+
+	fmt.Printf("Importing from %s:\n", file.Path)
+	pending := printImportPlan(changes)
+	if confirmImport(pending, dryRun, yes) {
+		a.applyImport(changes, pending)
+	}
+}
+
+// confirmImport 预览后确认是否写入：dry-run 或没有需要写入的配置时不写入，未指定 -y 时询问
+func confirmImport(pending int, dryRun, yes bool) bool {
+	if dryRun {
+		fmt.Println("Dry run, nothing was written.")
+		return false
+	}
+	if pending == 0 {
+		fmt.Println("Nothing to import.")
+		return false
+	}
+
+	if !yes {
+		answer, err := auth.PromptLine(fmt.Sprintf("Write %d configs? [y/N] ", pending))
+		if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+			fmt.Println("Import cancelled.")
+			return false
+		}
+	}
+	return true
+}
+
+// applyImport 写入需要导入的配置，先保存密钥文件，导入的明文密码存入保险库
+func (a *app) applyImport(changes []importChange, pending int) {
+	// 保险库未初始化时拒绝导入密码，不写入任何配置
+	if !vault.Exists(a.auth.GetVaultPath()) {
+		for _, change := range changes {
+			if change.apply && hasNewPassword(change) {
+				fmt.Fprintf(os.Stderr, "Error: %s has a password, %v\n", change.result.GetKey(), auth.ErrNoVault)
+				os.Exit(1)
+			}
+		}
+	}
+
+	for _, change := range changes {
+		if !change.apply {
+			continue
+		}
+		for path, content := range change.keys {
+			if err := bundle.InstallKey(path, content); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to save key file for %s: %v\n", change.result.GetKey(), err)
+				os.Exit(1)
+			}
+		}
+		if hasNewPassword(change) {
+			if err := a.auth.SealPassword(change.result); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to save password of %s to vault: %v\n", change.result.GetKey(), err)
+				os.Exit(1)
+			}
+		}
+		if err := a.store.Put(change.result); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save config %s: %v\n", change.result.GetKey(), err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("Imported %d configs.\n", pending)
+}
+
+// hasNewPassword 导入结果中是否有需要存入保险库的密码
+func hasNewPassword(change importChange) bool {
+	if change.result.Password == "" {
+		return false
+	}
+	return change.existing == nil || change.result.Password != change.existing.Password
+}
+
+// planImport 计算每个主机的导入结果和与现有配置的差异
+func planImport(file *sshconfig.File, store *config.ConfigStore, conflict string) []importChange {
+	var changes []importChange
+	seen := make(map[string]string) // 配置键 -> 首个使用该键的主机名
+
+	for _, alias := range file.Hosts() {
+		imported, err := file.Resolve(alias)
+		if err != nil {
+			changes = append(changes, importChange{alias: alias, note: err.Error()})
+			continue
+		}
+
+		key := imported.GetKey()
+		if first, ok := seen[key]; ok {
+			changes = append(changes, importChange{alias: alias, result: imported, note: fmt.Sprintf("same host as %s", first)})
+			continue
+		}
+		seen[key] = alias
+
+		change := importChange{alias: alias, result: imported}
+
+		// 别名已被其他配置使用时不导入别名
+		if owner, ok := store.FindAlias(imported.Alias); ok && imported.Alias != "" && owner != key {
+			change.warning = fmt.Sprintf("alias %s is already used by %s, not imported", imported.Alias, owner)
+			imported.Alias = ""
+		}
+
+		if existing, ok := store.Items[key]; ok {
+			change.existing = &existing
+			switch conflict {
+			case "skip":
+				change.action = "conflicts with existing config, skipped"
+			case "overwrite":
+				change.action = "overwrites existing config"
+				if imported.Alias == "" {
+					imported.Alias = existing.Alias
+				}
+				// ~/.ssh/config 中通常没有分组和标签，未设置时保留已有的
+				if imported.Group == "" {
+					imported.Group = existing.Group
+				}
+				if len(imported.Tags) == 0 {
+					imported.Tags = existing.Tags
+				}
+				imported.Notes = existing.Notes
+				imported.Password = existing.Password
+				imported.PasswordRef = existing.PasswordRef
+				imported.LastUsed = existing.LastUsed
+			case "merge":
+				change.action = "merged into existing config"
+				merged := existing
+				mergeMissing(&merged, imported)
+				change.result = &merged
+			}
+			change.diffs = diffConfigs(&existing, change.result)
+			change.apply = conflict != "skip" && len(change.diffs) > 0
+		} else {
+			change.diffs = diffConfigs(&config.SSHConfig{}, imported)
+			change.apply = true
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// printImportPlan 显示导入预览，返回需要写入的配置数量
+func printImportPlan(changes []importChange) int {
+	var added, updated, unchanged, skipped int
+	for _, change := range changes {
+		if change.note != "" {
+			fmt.Printf("  ! %s: %s, skipped\n", change.alias, change.note)
+			skipped++
+			continue
+		}
+
+		name := change.alias
+		if key := change.result.GetKey(); name != key {
+			name += " -> " + key
+		}
+		switch {
+		case change.existing == nil:
+			fmt.Printf("  + %s\n", name)
+			added++
+		case len(change.diffs) == 0:
+			fmt.Printf("  = %s (unchanged)\n", name)
+			unchanged++
+		case !change.apply:
+			fmt.Printf("  ~ %s (%s)\n", name, change.action)
+			skipped++
+		default:
+			fmt.Printf("  ~ %s (%s)\n", name, change.action)
+			updated++
+		}
+		if len(change.diffs) > 0 {
+			printDiffs(change.diffs)
+		}
+		printWarning(change.warning)
+	}
+	fmt.Printf("%d new, %d updated, %d unchanged, %d skipped\n", added, updated, unchanged, skipped)
+	return added + updated
+}
+
+// printDiffs 显示字段差异
+func printDiffs(diffs []string) {
+	for _, diff := range diffs {
+		fmt.Printf("      %s\n", diff)
+	}
+}
+
+// printWarning 显示导入时忽略的设置
+func printWarning(warning string) {
+	if warning != "" {
+		fmt.Printf("      (%s)\n", warning)
+	}
+}
+
+// diffConfigs 比较两个配置中可导入的字段，返回 "字段: 旧值 -> 新值" 形式的差异
+func diffConfigs(old, new *config.SSHConfig) []string {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"alias", old.Alias, new.Alias},
+		{"group", old.Group, new.Group},
+		{"tags", strings.Join(old.Tags, ","), strings.Join(new.Tags, ",")},
+		{"notes", old.Notes, new.Notes},
+		{"private_key", old.PrivateKey, new.PrivateKey},
+		{"certificate", old.Certificate, new.Certificate},
+		{"identities_only", formatBool(old.IdentitiesOnly), formatBool(new.IdentitiesOnly)},
+		{"forward_agent", formatBool(old.ForwardAgent), formatBool(new.ForwardAgent)},
+		{"proxy_jump", old.ProxyJump, new.ProxyJump},
+		{"ciphers", old.Ciphers, new.Ciphers},
+		{"macs", old.MACs, new.MACs},
+		{"kex_algorithms", old.KeyExchanges, new.KeyExchanges},
+		{"host_key_algorithms", old.HostKeyAlgorithms, new.HostKeyAlgorithms},
+	}
+
+	var diffs []string
+	for _, field := range fields {
+		switch {
+		case field.old == field.new:
+		case field.old == "":
+			diffs = append(diffs, fmt.Sprintf("%s: %s", field.name, field.new))
+		default:
+			diffs = append(diffs, fmt.Sprintf("%s: %s -> %s", field.name, field.old, orNone(field.new)))
+		}
+	}
+
+	// 密码不显示明文
+	if new.Password != "" && new.Password != old.Password {
+		if old.HasSavedPassword() {
+			diffs = append(diffs, "password: ******** -> (replaced)")
+		} else {
+			diffs = append(diffs, "password: ********")
+		}
+	}
+	return diffs
+}
+
+// mergeMissing 只补充现有配置中未设置的字段，标签取并集
+func mergeMissing(dst, src *config.SSHConfig) {
+	if dst.Alias == "" {
+		dst.Alias = src.Alias
+	}
+	if dst.Group == "" {
+		dst.Group = src.Group
+	}
+	dst.AddTags(src.Tags...)
+	if dst.PrivateKey == "" {
+		dst.PrivateKey = src.PrivateKey
+	}
+	if dst.Certificate == "" {
+		dst.Certificate = src.Certificate
+	}
+	dst.IdentitiesOnly = dst.IdentitiesOnly || src.IdentitiesOnly
+	dst.ForwardAgent = dst.ForwardAgent || src.ForwardAgent
+	if dst.ProxyJump == "" {
+		dst.ProxyJump = src.ProxyJump
+	}
+	if dst.Ciphers == "" {
+		dst.Ciphers = src.Ciphers
+	}
+	if dst.MACs == "" {
+		dst.MACs = src.MACs
+	}
+	if dst.KeyExchanges == "" {
+		dst.KeyExchanges = src.KeyExchanges
+	}
+	if dst.HostKeyAlgorithms == "" {
+		dst.HostKeyAlgorithms = src.HostKeyAlgorithms
+	}
+}
+
+// formatBool 布尔值显示为 yes，false 显示为空
+func formatBool(value bool) string {
+	if value {
+		return "yes"
+	}
+	return ""
+}
+
+// orNone 空值显示为 <none>
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
@@ -0,0 +1,93 @@
+// cmd/keygen.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/wuxs/ssm/pkg/auth"
+)
+
+var keygenCmd = &cobra.Command{
+	Use:   "keygen [flags]",
+	Short: "Generate an ed25519 key pair in OpenSSH format",
+	Long: `Generate an ed25519 key pair in OpenSSH format.
+
+The private key is written with mode 0600 and the public key to <file>.pub.
+Existing keys are never overwritten. You are prompted for a passphrase to
+encrypt the private key unless --no-passphrase is given.
+
+Examples:
+  ssm keygen                                 # Generate ~/.ssh/id_ed25519
+  ssm keygen -f ~/.ssh/work -C me@work       # Custom file and comment
+  ssm keygen --no-passphrase -f ./ci_key     # Unencrypted key for automation`,
+	Args: cobra.NoArgs,
+	Run:  runKeygenCommand,
+}
+
+func init() {
+	keygenCmd.Flags().StringP("file", "f", "", "Private key file to create (default is ~/.ssh/id_ed25519)")
+	keygenCmd.Flags().StringP("comment", "C", "", "Key comment (default is user@hostname)")
+	keygenCmd.Flags().Bool("no-passphrase", false, "Do not encrypt the private key")
+
+	rootCmd.AddCommand(keygenCmd)
+}
+
+func runKeygenCommand(cmd *cobra.Command, args []string) {
+	privateKeyPath, _ := cmd.Flags().GetString("file")
+	comment, _ := cmd.Flags().GetString("comment")
+	noPassphrase, _ := cmd.Flags().GetBool("no-passphrase")
+
+	if privateKeyPath == "" {
+		privateKeyPath = filepath.Join(os.Getenv("HOME"), ".ssh", "id_ed25519")
+	}
+	if comment == "" {
+		comment = auth.GetDefaultComment()
+	}
+
+	if _, err := os.Stat(privateKeyPath); err == nil {
+		fmt.Fprintf(os.Stderr, "Error: %s already exists\n", privateKeyPath)
+		os.Exit(1)
+	}
+
+	passphrase := ""
+	if !noPassphrase {
+		var err error
+		passphrase, err = promptNewKeyPassphrase()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	publicKey, err := auth.GenerateKey(privateKeyPath, comment, passphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate key: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Your identification has been saved in %s\n", privateKeyPath)
+	fmt.Printf("Your public key has been saved in %s.pub\n", privateKeyPath)
+	fmt.Printf("The key fingerprint is:\n%s %s\n", ssh.FingerprintSHA256(publicKey), comment)
+}
+
+// promptNewKeyPassphrase 提示输入两次私钥密码，留空表示不加密
+func promptNewKeyPassphrase() (string, error) {
+	passphrase, err := auth.PromptPassword("Enter passphrase (empty for no passphrase): ")
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+
+	confirm, err := auth.PromptPassword("Enter same passphrase again: ")
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	if passphrase != confirm {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
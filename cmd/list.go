@@ -0,0 +1,476 @@
+// cmd/list.go
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+// defaultListColumns --list 默认显示的列
+var defaultListColumns = []string{"key", "alias", "auth", "proxy_jump", "tags", "last_used"}
+
+// listColumnNames --list 可以显示、排序和筛选的列：配置键、认证方式和配置的所有字段（不包括明文密码）
+func listColumnNames() []string {
+	names := []string{"key", "auth"}
+	for _, name := range config.FieldNames() {
+		if name != "password" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// listValue 列的值：配置字段为原始类型（string、bool 或 []string），key 和 auth 为字符串
+func listValue(cfg *config.SSHConfig, column string) any {
+	switch column {
+	case "key":
+		return cfg.GetKey()
+	case "auth":
+		return cfg.GetAuthMethod()
+	}
+	value, _ := cfg.Field(column)
+	return value
+}
+
+// listString 列的文本值，列表以逗号分隔
+func listString(cfg *config.SSHConfig, column string) string {
+	switch value := listValue(cfg, column).(type) {
+	case []string:
+		return strings.Join(value, ",")
+	case bool:
+		return strconv.FormatBool(value)
+	case string:
+		return value
+	default:
+		return ""
+	}
+}
+
+// listFlags --list 的输出、列、排序和筛选参数
+type listFlags struct {
+	output  string
+	columns []string
+	sort    []string
+	filters []listFilter
+	tags    []string
+}
+
+// listFilter 筛选条件，如 tags=web、alias~db、last_used>7d
+type listFilter struct {
+	column string
+	op     string
+	value  string
+}
+
+// listFilterOps 筛选运算符，两个字符的在前，同一位置优先匹配
+var listFilterOps = []string{"!=", "!~", "=", "~", "<", ">"}
+
+// parseListFilter 解析筛选条件
+func parseListFilter(expr string) (listFilter, error) {
+	best, op := -1, ""
+	for _, candidate := range listFilterOps {
+		if i := strings.Index(expr, candidate); i > 0 && (best < 0 || i < best) {
+			best, op = i, candidate
+		}
+	}
+	if best < 0 {
+		return listFilter{}, fmt.Errorf("invalid filter %q: use column=value, column!=value, column~text, column!~text, column<value or column>value", expr)
+	}
+
+	filter := listFilter{column: strings.TrimSpace(expr[:best]), op: op, value: strings.TrimSpace(expr[best+len(op):])}
+	if !slices.Contains(listColumnNames(), filter.column) {
+		return listFilter{}, fmt.Errorf("invalid filter %q: unknown column %q", expr, filter.column)
+	}
+	if op == "<" || op == ">" {
+		switch filter.column {
+		case "last_used":
+			if _, err := parseHistoryTime(filter.value, time.Now(), false); err != nil {
+				return listFilter{}, fmt.Errorf("invalid filter %q: %v", expr, err)
+			}
+		case "port":
+			if _, err := strconv.Atoi(filter.value); err != nil {
+				return listFilter{}, fmt.Errorf("invalid filter %q: port must be a number", expr)
+			}
+		default:
+			return listFilter{}, fmt.Errorf("invalid filter %q: %s only works with last_used and port", expr, op)
+		}
+	}
+	return filter, nil
+}
+
+// match 配置是否满足筛选条件。= 和 != 不区分大小写，支持 * 和 ? 通配符；
+// ~ 和 !~ 为包含子串；列表字段中任一项满足即可；last_used 的 < 和 > 比较时间（从未使用视为最早）
+func (f listFilter) match(cfg *config.SSHConfig, now time.Time) bool {
+	switch f.op {
+	case "!=":
+		return !listFilter{f.column, "=", f.value}.match(cfg, now)
+	case "!~":
+		return !listFilter{f.column, "~", f.value}.match(cfg, now)
+	case "<", ">":
+		return f.compare(cfg, now)
+	}
+
+	values := []string{listString(cfg, f.column)}
+	if tags, ok := listValue(cfg, f.column).([]string); ok {
+		values = tags
+		if len(tags) == 0 {
+			values = []string{""}
+		}
+	}
+	for _, value := range values {
+		if f.op == "~" && strings.Contains(strings.ToLower(value), strings.ToLower(f.value)) {
+			return true
+		}
+		if f.op == "=" && matchListValue(f.value, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare 按时间或端口号比较
+func (f listFilter) compare(cfg *config.SSHConfig, now time.Time) bool {
+	var cmp int
+	if f.column == "port" {
+		port, _ := strconv.Atoi(cfg.Port)
+		limit, _ := strconv.Atoi(f.value)
+		cmp = port - limit
+	} else {
+		limit, _ := parseHistoryTime(f.value, now, false)
+		cmp = parseLastUsed(cfg).Compare(limit)
+	}
+	if f.op == "<" {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+// matchListValue 不区分大小写比较，pattern 中可以使用 * 和 ? 通配符
+func matchListValue(pattern, value string) bool {
+	if strings.ContainsAny(pattern, "*?[") {
+		matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+		return matched
+	}
+	return strings.EqualFold(pattern, value)
+}
+
+// parseListFlags 读取并检查 --list 的参数
+func parseListFlags(cmd *cobra.Command) (*listFlags, error) {
+	flags := &listFlags{}
+	flags.output, _ = cmd.Flags().GetString("output")
+	flags.tags, _ = cmd.Flags().GetStringSlice("tag")
+	switch flags.output {
+	case "", "table", "json", "yaml", "csv":
+	default:
+		return nil, fmt.Errorf("invalid output %q: use table, json, yaml or csv", flags.output)
+	}
+
+	names := listColumnNames()
+	flags.columns, _ = cmd.Flags().GetStringSlice("columns")
+	for _, column := range flags.columns {
+		if !slices.Contains(names, column) {
+			return nil, fmt.Errorf("unknown column %q, available columns: %s", column, strings.Join(names, ", "))
+		}
+	}
+	if len(flags.columns) == 0 {
+		flags.columns = defaultListColumns
+	} else if flags.output == "" {
+		flags.output = "table"
+	}
+
+	flags.sort, _ = cmd.Flags().GetStringSlice("sort")
+	for _, key := range flags.sort {
+		if column := strings.TrimPrefix(key, "-"); !slices.Contains(names, column) {
+			return nil, fmt.Errorf("unknown sort column %q, available columns: %s", column, strings.Join(names, ", "))
+		}
+	}
+
+	exprs, _ := cmd.Flags().GetStringArray("filter")
+	for _, expr := range exprs {
+		filter, err := parseListFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		flags.filters = append(flags.filters, filter)
+	}
+	return flags, nil
+}
+
+// selectConfigs 按标签和筛选条件选择主机（不包括模式）并排序
+func selectConfigs(configs []config.SSHConfig, flags *listFlags, now time.Time) []config.SSHConfig {
+	configs, _ = config.SplitPatterns(configs)
+	configs = config.FilterByTags(configs, flags.tags)
+
+	var selected []config.SSHConfig
+	for i := range configs {
+		matched := true
+		for _, filter := range flags.filters {
+			if !filter.match(&configs[i], now) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			selected = append(selected, configs[i])
+		}
+	}
+
+	// 没有指定排序时保持最后使用时间倒序
+	sort.SliceStable(selected, func(i, j int) bool {
+		for _, key := range flags.sort {
+			column, desc := strings.CutPrefix(key, "-")
+			if cmp := compareListColumn(&selected[i], &selected[j], column); cmp != 0 {
+				return (cmp < 0) != desc
+			}
+		}
+		return false
+	})
+	return selected
+}
+
+// compareListColumn 按列比较两个配置：端口按数字、最后使用时间按时间、其他按文本
+func compareListColumn(a, b *config.SSHConfig, column string) int {
+	switch column {
+	case "port":
+		pa, _ := strconv.Atoi(a.Port)
+		pb, _ := strconv.Atoi(b.Port)
+		return pa - pb
+	case "last_used":
+		return parseLastUsed(a).Compare(parseLastUsed(b))
+	default:
+		return strings.Compare(strings.ToLower(listString(a, column)), strings.ToLower(listString(b, column)))
+	}
+}
+
+// parseLastUsed 最后使用时间，从未使用时为零值
+func parseLastUsed(cfg *config.SSHConfig) time.Time {
+	t, _ := time.Parse(time.RFC3339, cfg.LastUsed)
+	return t
+}
+
+// runList 列出连接配置：没有指定 --output 和 --columns 时按分组显示，否则输出表格、JSON、YAML 或 CSV
+func runList(cmd *cobra.Command) error {
+	a := appFrom(cmd)
+	flags, err := parseListFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	configs, err := a.store.List()
+	if err != nil {
+		return fmt.Errorf("failed to load configs: %v", err)
+	}
+	now := time.Now()
+	configs = selectConfigs(configs, flags, now)
+
+	out := cmd.OutOrStdout()
+	switch flags.output {
+	case "":
+		displayConfigs(configs, flags.tags)
+		return nil
+	case "table":
+		writeListTable(out, configs, flags.columns, now)
+		return nil
+	case "json":
+		return writeListJSON(out, configs, flags.columns)
+	case "yaml":
+		writeListYAML(out, configs, flags.columns)
+		return nil
+	default:
+		return writeListCSV(out, configs, flags.columns)
+	}
+}
+
+// writeListTable 输出对齐的表格，最后使用时间显示为相对时间
+func writeListTable(w io.Writer, configs []config.SSHConfig, columns []string, now time.Time) {
+	if len(configs) == 0 {
+		fmt.Fprintln(w, "No SSH connection configurations found.")
+		return
+	}
+
+	rows := [][]string{make([]string, len(columns))}
+	for i, column := range columns {
+		rows[0][i] = strings.ToUpper(column)
+	}
+	for i := range configs {
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			switch column {
+			case "last_used":
+				row[j] = relativeTime(parseLastUsed(&configs[i]), now)
+			case "tags":
+				row[j] = strings.Join(configs[i].Tags, ", ")
+			default:
+				row[j] = listString(&configs[i], column)
+			}
+			if row[j] == "" {
+				row[j] = "-"
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(columns))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if i == len(row)-1 {
+				line.WriteString(cell)
+			} else {
+				fmt.Fprintf(&line, "%-*s  ", widths[i], cell)
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+	}
+}
+
+// writeListJSON 输出 JSON 数组，字段顺序与列顺序一致
+func writeListJSON(w io.Writer, configs []config.SSHConfig, columns []string) error {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := range configs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for j, column := range columns {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			value := listValue(&configs[i], column)
+			if tags, ok := value.([]string); ok && tags == nil {
+				value = []string{}
+			}
+			name, _ := json.Marshal(column)
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			buf.Write(name)
+			buf.WriteByte(':')
+			buf.Write(data)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// writeListYAML 输出 YAML 列表，每个主机一个映射
+func writeListYAML(w io.Writer, configs []config.SSHConfig, columns []string) {
+	if len(configs) == 0 {
+		fmt.Fprintln(w, "[]")
+		return
+	}
+	for i := range configs {
+		for j, column := range columns {
+			prefix := "  "
+			if j == 0 {
+				prefix = "- "
+			}
+
+			var value string
+			switch v := listValue(&configs[i], column).(type) {
+			case []string:
+				items := make([]string, len(v))
+				for k, item := range v {
+					items[k] = yamlScalar(item)
+				}
+				value = "[" + strings.Join(items, ", ") + "]"
+			case bool:
+				value = strconv.FormatBool(v)
+			case string:
+				value = yamlScalar(v)
+			}
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, column, value)
+		}
+	}
+}
+
+// yamlPlain 可以不加引号的 YAML 字符串
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_./~][A-Za-z0-9_./~@:+=()-]*$`)
+
+// yamlScalar YAML 字符串值：可能被解析为其他类型或包含特殊字符时使用双引号（JSON 字符串语法）
+func yamlScalar(s string) string {
+	plain := yamlPlain.MatchString(s) && !strings.HasSuffix(s, ":")
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "y", "n", "~":
+		plain = false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		plain = false
+	}
+	if plain {
+		return s
+	}
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// writeListCSV 输出带表头的 CSV，列表字段以逗号分隔
+func writeListCSV(w io.Writer, configs []config.SSHConfig, columns []string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	for i := range configs {
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			row[j] = listString(&configs[i], column)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// relativeTime 相对时间，如 "5m ago"、"3d ago"，零值为 "never"
+func relativeTime(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
+	}
+}
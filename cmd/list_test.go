@@ -0,0 +1,104 @@
+// cmd/list_test.go
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+func TestSelectConfigs(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	configs := []config.SSHConfig{
+		{Alias: "web1", Host: "10.0.0.1", Username: "root", Port: "22", Tags: []string{"prod", "web"}, LastUsed: "2026-03-10T11:00:00Z"},
+		{Alias: "db1", Host: "10.0.0.2", Username: "postgres", Port: "2222", Tags: []string{"db", "prod"}, LastUsed: "2026-02-01T00:00:00Z"},
+		{Host: "staging.example.com", Username: "deploy", Port: "22", ProxyJump: "bastion"},
+		{Host: "*.example.com", PrivateKey: "/keys/example"},
+	}
+
+	tests := []struct {
+		filters []string
+		sort    []string
+		want    []string
+	}{
+		{nil, nil, []string{"root@10.0.0.1:22", "postgres@10.0.0.2:2222", "deploy@staging.example.com:22"}},
+		{[]string{"tags=prod"}, []string{"alias"}, []string{"postgres@10.0.0.2:2222", "root@10.0.0.1:22"}},
+		{[]string{"tags!=prod"}, nil, []string{"deploy@staging.example.com:22"}},
+		{[]string{"host=10.0.0.*", "port>22"}, nil, []string{"postgres@10.0.0.2:2222"}},
+		{[]string{"proxy_jump~BAST"}, nil, []string{"deploy@staging.example.com:22"}},
+		{[]string{"last_used>7d"}, nil, []string{"root@10.0.0.1:22"}},
+		{[]string{"last_used<7d"}, []string{"-port", "key"}, []string{"postgres@10.0.0.2:2222", "deploy@staging.example.com:22"}},
+	}
+	for _, tt := range tests {
+		flags := &listFlags{sort: tt.sort}
+		for _, expr := range tt.filters {
+			filter, err := parseListFilter(expr)
+			if err != nil {
+				t.Fatalf("parseListFilter(%q) error = %v", expr, err)
+			}
+			flags.filters = append(flags.filters, filter)
+		}
+
+		selected := selectConfigs(configs, flags, now)
+		var got []string
+		for _, cfg := range selected {
+			got = append(got, cfg.GetKey())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("filters %v sort %v = %v, want %v", tt.filters, tt.sort, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("filters %v sort %v = %v, want %v", tt.filters, tt.sort, got, tt.want)
+				break
+			}
+		}
+	}
+
+	for _, expr := range []string{"nosuch=1", "alias>3", "tags", "last_used<soon"} {
+		if _, err := parseListFilter(expr); err == nil {
+			t.Errorf("parseListFilter(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestWriteListJSON(t *testing.T) {
+	configs := []config.SSHConfig{{Alias: "web1", Host: "10.0.0.1", Username: "root", Port: "22", PrivateKey: "/k"}}
+
+	var buf bytes.Buffer
+	if err := writeListJSON(&buf, configs, []string{"alias", "auth", "tags", "forward_agent"}); err != nil {
+		t.Fatalf("writeListJSON() error = %v", err)
+	}
+
+	var got []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(got) != 1 || got[0]["alias"] != "web1" || got[0]["auth"] != "key" || got[0]["forward_agent"] != false {
+		t.Errorf("writeListJSON() = %v", got)
+	}
+	if tags, ok := got[0]["tags"].([]any); !ok || len(tags) != 0 {
+		t.Errorf("tags = %#v, want an empty list", got[0]["tags"])
+	}
+}
+
+func TestYAMLScalar(t *testing.T) {
+	tests := map[string]string{
+		"web1":                 "web1",
+		"root@10.0.0.1:22":     "root@10.0.0.1:22",
+		"2026-03-10T11:00:00Z": `"2026-03-10T11:00:00Z"`,
+		"22":                   `"22"`,
+		"yes":                  `"yes"`,
+		"":                     `""`,
+		"a: b":                 `"a: b"`,
+	}
+	for in, want := range tests {
+		if got := yamlScalar(in); got != want {
+			t.Errorf("yamlScalar(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// cmd/pick.go
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/terminal"
+)
+
+// pickHost 不带参数运行时在终端中选择已保存的主机，按最后使用时间排序，返回配置键，取消选择时为空。
+// 标准输入或输出不是终端、没有保存的主机时不显示选择列表，shown 为 false
+func (a *app) pickHost() (host string, shown bool) {
+	if !terminal.IsTerminal(os.Stdin) || !terminal.IsTerminal(os.Stdout) {
+		return "", false
+	}
+
+	configs, err := a.store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configs: %v\n", err)
+		return "", false
+	}
+	configs, _ = config.SplitPatterns(configs)
+	if len(configs) == 0 {
+		return "", false
+	}
+
+	items := make([]terminal.PickItem, len(configs))
+	for i := range configs {
+		items[i] = pickItem(&configs[i])
+	}
+
+	i, err := terminal.NewTerminalManager().Pick(os.Stdin, os.Stdout, "Host> ", items)
+	if err != nil {
+		if !errors.Is(err, terminal.ErrPickCancelled) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		return "", true
+	}
+	return configs[i].GetKey(), true
+}
+
+// pickItem 主机在选择列表中的显示和搜索文本：配置键、别名、标签和备注
+func pickItem(cfg *config.SSHConfig) terminal.PickItem {
+	label := cfg.GetKey()
+	if cfg.Alias != "" {
+		label = fmt.Sprintf("%-20s %s", cfg.Alias, label)
+	}
+	if len(cfg.Tags) > 0 {
+		label += fmt.Sprintf(" [%s]", strings.Join(cfg.Tags, ", "))
+	}
+	if cfg.Notes != "" {
+		label += "  # " + cfg.Notes
+	}
+
+	search := strings.Join(append([]string{cfg.GetKey(), cfg.Alias, cfg.Group, cfg.Notes}, cfg.Tags...), " ")
+	return terminal.PickItem{Label: label, Search: search}
+}
@@ -0,0 +1,108 @@
+// cmd/profile.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Switch between named config profiles",
+	Long: `Switch between named config profiles. Each profile is a separate connection
+config file; the default profile is ~/.ssm/ssh_config.json and other profiles are
+stored in ~/.ssm/profiles/<name>.json. The vault and known_hosts are shared.
+
+The config file is chosen in this order:
+  --config <file>      Use the given config file
+  --profile <name>     Use the named profile for this command
+  SSM_CONFIG=<file>    Use the given config file
+  ssm profile use      The profile selected with 'ssm profile use'
+SSM_HOME moves the whole ~/.ssm directory, including profiles and the vault.
+
+Examples:
+  ssm profile use work         # Use the "work" profile from now on
+  ssm --profile home --list    # Use the "home" profile for one command
+  ssm profile use default      # Go back to the default profile
+  ssm profile list             # List profiles`,
+	// 切换配置不需要打开配置存储，当前配置无法使用时也能切换
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List profiles, the active one is marked with *",
+	Args:    cobra.NoArgs,
+	Run:     runProfileList,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use name",
+	Short: "Select the profile used by later commands",
+	Args:  cobra.ExactArgs(1),
+	Run:   runProfileUse,
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+
+	rootCmd.AddCommand(profileCmd)
+}
+
+func runProfileList(cmd *cobra.Command, args []string) {
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	active := config.GetActiveProfile()
+	if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+		active = profile
+	}
+
+	for _, name := range profiles {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+
+		path := config.GetProfilePath(name)
+		status := "no connections"
+		if configs, err := config.NewFileStore(path).List(); err != nil {
+			status = fmt.Sprintf("error: %v", err)
+		} else if len(configs) > 0 {
+			status = pluralize(len(configs), "connection")
+		}
+		fmt.Printf("%s %-16s %s (%s)\n", marker, name, path, status)
+	}
+
+	if configFile, _ := cmd.Flags().GetString("config"); configFile != "" {
+		fmt.Printf("Note: --config overrides profiles, using %s\n", configFile)
+	} else if configFile := os.Getenv("SSM_CONFIG"); configFile != "" && !cmd.Flags().Changed("profile") {
+		fmt.Printf("Note: SSM_CONFIG overrides profiles, using %s\n", configFile)
+	}
+}
+
+func runProfileUse(cmd *cobra.Command, args []string) {
+	name := args[0]
+	if err := config.SetActiveProfile(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	path := config.GetProfilePath(name)
+	fmt.Printf("Using profile %s (%s)\n", name, path)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Println("The profile has no connections yet, it is created when the first connection is saved.")
+	}
+	if os.Getenv("SSM_CONFIG") != "" {
+		fmt.Println("Note: SSM_CONFIG is set and overrides the selected profile.")
+	}
+}
@@ -2,22 +2,29 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh"
 
 	"github.com/wuxs/ssm/pkg/auth"
 	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/history"
+	"github.com/wuxs/ssm/pkg/jump"
 	"github.com/wuxs/ssm/pkg/terminal"
 	"github.com/wuxs/ssm/pkg/utils"
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "ssm [user@]hostname[:port]",
+	Use:   "ssm [user@]hostname[:port] | alias",
 	Short: "Simple SSH Manager - Connect to remote servers and manage SSH connections",
 	Long: `Simple SSH Manager (SSM) is a lightweight SSH connection management tool.
 It simplifies SSH connections with intelligent configuration management,
@@ -25,26 +32,83 @@ multiple authentication methods, and jump host support.
 
 Available Commands:
   cp         Copy files to/from remote servers using SFTP
+  cert       Inspect OpenSSH user certificates
+  vault      Manage the encrypted credential vault
+  keygen     Generate an ed25519 key pair in OpenSSH format
+  copy-id    Install your public key on a remote server
+  algorithms Show the algorithms negotiated with a server
+  import     Import hosts from a bundle or ~/.ssh/config
+  export     Export saved connections as a bundle or an OpenSSH config
+  alias      Manage host aliases
+  tag        Manage host tags
+  group      Manage host groups
+  exec       Run a command on one or more hosts
+  profile    Switch between named config profiles
+  config     Edit saved configs, pattern defaults and effective host configs
+  history    Show the connection history
 
 Examples:
+  ssm                                            # Pick a saved host with fuzzy search
   ssm user@hostname                              # Connect to remote server
   ssm user@hostname:2222                         # Connect with custom port
+  ssm prod-db                                    # Connect using an alias (see 'ssm alias')
   ssm -J jumphost user@target                    # Connect via jump host
   ssm -J user@jumphost:2222 user@target:22       # Connect via jump host with custom port
+  ssm -J bastion,inner user@target               # Connect through a chain of jump hosts
+  ssm -A user@hostname                           # Connect with agent forwarding
+  ssm --kex +diffie-hellman-group1-sha1 old-gear # Enable a legacy key exchange for this host
   ssm cp file.txt user@host:/remote/path         # Copy file to remote
-  ssm cp user@host:/remote/file.txt ./           # Copy file from remote`,
-	Args: cobra.MaximumNArgs(1),
-	Run:  runSSHCommand,
+  ssm cp user@host:/remote/file.txt ./           # Copy file from remote
+  ssm --list --tag prod                          # List hosts tagged prod
+  ssm --list -o json --filter tags=prod          # Machine-readable list of prod hosts
+  ssm --list --columns alias,auth --sort alias   # Pick the columns and the order
+  ssm exec --tag web -- uptime                   # Run a command on every host tagged web
+  ssm --profile work --list                      # List connections of the "work" profile
+  ssm --config ./team.json prod-db               # Use another config file`,
+	Args:             cobra.MaximumNArgs(1),
+	PersistentPreRun: openApp,
+	Run:              runSSHCommand,
+}
+
+// app 子命令共享的依赖：连接配置存储、认证管理器和连接历史，执行命令前打开并放入命令的 context
+type app struct {
+	store   config.Store
+	auth    *auth.AuthManager
+	history *history.Log // 为空时不记录连接历史（测试中不写入 ~/.ssm）
+}
+
+// appKey 命令 context 中保存 app 的键
+type appKey struct{}
+
+// appFrom 获取执行命令前放入 context 的 app
+func appFrom(cmd *cobra.Command) *app {
+	ctx := cmd.Context()
+	if ctx == nil {
+		return nil
+	}
+	a, _ := ctx.Value(appKey{}).(*app)
+	return a
 }
 
 func init() {
-	rootCmd.Flags().StringP("identity", "i", "", "Private key file for authentication (default is ~/.ssh/id_rsa)")
+	rootCmd.PersistentFlags().String("config", "", "Connection config file to use (default is ~/.ssm/ssh_config.json, or $SSM_CONFIG)")
+	rootCmd.PersistentFlags().String("profile", "", "Named config profile to use for this command (see 'ssm profile')")
+	rootCmd.Flags().StringP("identity", "i", "", "Private key file for authentication (default: ssh-agent keys and ~/.ssh/id_*)")
 	rootCmd.Flags().StringP("port", "p", "", "Port to connect to on the remote host")
-	rootCmd.Flags().StringP("proxy-jump", "J", "", "Connect via jump host. Format: [user@]hostname[:port]")
+	rootCmd.Flags().StringP("proxy-jump", "J", "", "Connect via jump hosts, saved per host. Format: [user@]hostname[:port][,...] or alias, 'none' to clear")
+	rootCmd.Flags().String("certificate", "", "OpenSSH user certificate to use with the private key (default is <key>-cert.pub)")
+	rootCmd.Flags().Bool("identities-only", false, "Only offer the identity given by -i (from ssh-agent or key file), saved per host")
+	rootCmd.Flags().BoolP("forward-agent", "A", false, "Enable forwarding of the ssh-agent connection, saved per host (use --forward-agent=false to disable)")
 	rootCmd.Flags().BoolP("list", "l", false, "List SSH connection configurations")
-	rootCmd.Flags().StringP("delete", "d", "", "Delete SSH connection configuration by key (user@host:port)")
+	rootCmd.Flags().StringSlice("tag", []string{}, "With --list, only show hosts that have all of these tags")
+	rootCmd.Flags().StringP("output", "o", "", "With --list, output format: table, json, yaml or csv (default is a grouped list)")
+	rootCmd.Flags().StringSlice("columns", []string{}, "With --list, columns to show, e.g. key,alias,auth,proxy_jump,tags,last_used")
+	rootCmd.Flags().StringSlice("sort", []string{}, "With --list, sort by these columns, prefix - for descending (default is -last_used)")
+	rootCmd.Flags().StringArray("filter", []string{}, "With --list, only show hosts matching column=value, !=, ~ (contains), !~, or < and > for last_used and port")
+	rootCmd.Flags().StringP("delete", "d", "", "Delete SSH connection configuration by key (user@host:port) or alias")
 	rootCmd.Flags().StringSliceP("local-forward", "L", []string{}, "Local port forwarding, format: [local_port:]remote_host:remote_port")
 	rootCmd.Flags().StringSliceP("remote-forward", "R", []string{}, "Remote port forwarding, format: [remote_port:]local_host:local_port")
+	addAlgorithmFlags(rootCmd)
 }
 
 func Execute() {
@@ -54,75 +118,132 @@ func Execute() {
 	}
 }
 
+// openApp 执行命令前打开配置存储、认证管理器和连接历史：旧格式自动升级，更新格式的配置拒绝使用
+func openApp(cmd *cobra.Command, args []string) {
+	if appFrom(cmd) != nil {
+		return
+	}
+
+	configFlag, _ := cmd.Flags().GetString("config")
+	profileFlag, _ := cmd.Flags().GetString("profile")
+	configFile, err := config.ResolveConfigFile(configFlag, profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fileStore := config.NewFileStore(configFile)
+	if _, err := fileStore.Load(); errors.Is(err, config.ErrNewerVersion) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	configDir := config.GetConfigDir()
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd.SetContext(context.WithValue(ctx, appKey{}, &app{
+		store:   fileStore,
+		auth:    auth.NewAuthManager(configDir),
+		history: history.Open(history.GetHistoryPath(configDir)),
+	}))
+}
+
 func runSSHCommand(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
 	// 检查是否要列出连接配置
 	if listConfigs, _ := cmd.Flags().GetBool("list"); listConfigs {
-		displayConfigs()
+		if err := runList(cmd); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 		return
 	}
 
 	// 检查是否要删除连接配置
 	if deleteKey, _ := cmd.Flags().GetString("delete"); deleteKey != "" {
-		handleDelete(deleteKey)
+		a.handleDelete(deleteKey)
 		return
 	}
 
-	// 如果没有参数，显示帮助信息
+	// 如果没有参数，在终端中选择已保存的主机；不是终端或没有保存的主机时显示帮助信息
 	if len(args) < 1 {
-		cmd.Help()
-		return
+		host, shown := a.pickHost()
+		if !shown {
+			cmd.Help()
+			return
+		}
+		if host == "" {
+			return
+		}
+		args = []string{host}
 	}
 
 	host := args[0]
 
-	// 解析主机信息
-	username, hostname, port := utils.ParseSSHHost(host)
+	// 解析主机信息（优先匹配别名）
+	username, hostname, port := a.resolveHostArg(host)
 
 	// 获取标志
 	privateKeyPath, _ := cmd.Flags().GetString("identity")
 	proxyJump, _ := cmd.Flags().GetString("proxy-jump")
 	localForwards, _ := cmd.Flags().GetStringSlice("local-forward")
 	remoteForwards, _ := cmd.Flags().GetStringSlice("remote-forward")
-	privateKeyPath = utils.GetDefaultPrivateKeyPath(privateKeyPath)
+	certificatePath, _ := cmd.Flags().GetString("certificate")
 
-	// 检查现有配置
+	// 检查现有配置，未设置的字段使用匹配模式的设置，命令行参数优先
 	key := utils.GetConfigKey(username, hostname, port)
-	sshConfig, exists := config.Get(key)
+	sshConfig, exists := a.store.Get(key)
 	if !exists {
 		// 创建新配置
 		sshConfig = &config.SSHConfig{
-			Host:       hostname,
-			Username:   username,
-			Port:       port,
-			PrivateKey: privateKeyPath,
-			ProxyJump:  proxyJump,
-		}
-	} else {
-		// 更新现有配置
-		if privateKeyPath != "" {
-			sshConfig.PrivateKey = privateKeyPath
-		}
-		if proxyJump != "" {
-			sshConfig.ProxyJump = proxyJump
+			Host:     hostname,
+			Username: username,
+			Port:     port,
 		}
 	}
+	config.ApplyPatterns(a.store, sshConfig)
 
-	// 建立SSH连接
-	if err := establishConnection(sshConfig, localForwards, remoteForwards); err != nil {
+	// 未指定 -i 时保留已保存（或导入、继承）的私钥，新配置不记录私钥，连接时自动查找 ssh-agent 与默认私钥
+	if privateKeyPath != "" {
+		sshConfig.PrivateKey = privateKeyPath
+	}
+	if certificatePath != "" {
+		sshConfig.Certificate = certificatePath
+	}
+	applyProxyJump(sshConfig, proxyJump)
+
+	// identities-only 按主机保存，显式指定时覆盖已保存的设置（包括 --identities-only=false）
+	// 设为 false 时不再继承模式的设置
+	if cmd.Flags().Changed("identities-only") {
+		sshConfig.IdentitiesOnly, _ = cmd.Flags().GetBool("identities-only")
+		sshConfig.TrackOverride("identities_only")
+	}
+	applyForwardAgent(cmd, sshConfig)
+	applyAlgorithmFlags(cmd, sshConfig)
+
+	// 建立SSH连接，结束后记录连接历史
+	entry := newHistoryEntry(history.KindSSH, sshConfig)
+	entry.Forwards = describeForwards(localForwards, remoteForwards)
+	err := a.establishConnection(sshConfig, localForwards, remoteForwards)
+	exitStatus, connErr := exitStatusOf(err)
+	a.recordHistory(entry, exitStatus, connErr)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Connection failed: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func establishConnection(cfg *config.SSHConfig, localForwards, remoteForwards []string) error {
+func (a *app) establishConnection(cfg *config.SSHConfig, localForwards, remoteForwards []string) error {
 	// 创建SSH客户端配置
-	clientConfig, err := auth.CreateClientConfig(cfg)
+	clientConfig, err := a.auth.CreateClientConfig(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create SSH config: %v", err)
 	}
 
 	// 连接SSH服务器（支持跳板机）
-	client, err := connectWithJump(cfg, clientConfig)
+	client, err := jump.Dial(a.store, a.auth, cfg, clientConfig, os.Stdout)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %v", err)
 	}
@@ -172,10 +293,16 @@ func establishConnection(cfg *config.SSHConfig, localForwards, remoteForwards []
 	}
 	defer session.Close()
 
-	// 连接成功，更新并保存配置
-	cfg.UpdateLastUsed()
-	if err := config.SaveConfig(cfg); err != nil {
-		fmt.Printf("Warning: Failed to save config: %v\n", err)
+	// 开启 agent 转发
+	if cfg.ForwardAgent {
+		if err := auth.ForwardAgent(client, session); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// 连接成功，更新并保存配置（密码只保存在保险库中）
+	if err := a.auth.SaveLogin(a.store, cfg); err != nil {
+		fmt.Printf("Warning: %v\n", err)
 	}
 
 	// 设置会话的输入输出
@@ -358,7 +485,7 @@ func startRemoteForward(client *ssh.Client, rf *RemoteForward) error {
 		}
 
 		// 连接到本地主机
-		localAddr := fmt.Sprintf("%s:%d", rf.localHost, rf.localPort)
+		localAddr := net.JoinHostPort(rf.localHost, strconv.Itoa(int(rf.localPort)))
 		localConn, err := net.Dial("tcp4", localAddr)
 		if err != nil {
 			remoteConn.Close()
@@ -386,98 +513,117 @@ func copyConn(dst net.Conn, src net.Conn) {
 	_, _ = io.Copy(dst, src)
 }
 
-func connectWithJump(cfg *config.SSHConfig, clientConfig *ssh.ClientConfig) (*ssh.Client, error) {
-	// 如果没有跳板机，直接连接
-	if cfg.ProxyJump == "" {
-		addr := cfg.Host + ":" + cfg.Port
-		fmt.Printf("Connecting to %s...\n", addr)
-		return ssh.Dial("tcp", addr, clientConfig)
-	}
-
-	// 解析跳板机配置
-	jumpConfig := parseJumpConfig(cfg.ProxyJump)
-
-	// 创建跳板机SSH配置
-	jumpClientConfig, err := auth.CreateClientConfig(jumpConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create jump host SSH config: %v", err)
-	}
-
-	// 连接跳板机
-	jumpAddr := jumpConfig.Host + ":" + jumpConfig.Port
-	fmt.Printf("Connecting to jump host %s...\n", jumpAddr)
-	jumpClient, err := ssh.Dial("tcp", jumpAddr, jumpClientConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to jump host: %v", err)
+// applyForwardAgent 应用 -A 参数：agent 转发按主机保存，显式指定时覆盖已保存的设置（包括 --forward-agent=false），
+// 设为 false 时不再继承模式的设置
+func applyForwardAgent(cmd *cobra.Command, sshConfig *config.SSHConfig) {
+	if cmd.Flags().Changed("forward-agent") {
+		sshConfig.ForwardAgent, _ = cmd.Flags().GetBool("forward-agent")
+		sshConfig.TrackOverride("forward_agent")
 	}
+}
 
-	// 保存跳板机配置（如果连接成功）
-	jumpConfig.UpdateLastUsed()
-	if err := config.SaveConfig(jumpConfig); err != nil {
-		fmt.Printf("Warning: Failed to save jump host config: %v\n", err)
+// applyProxyJump 应用 -J 参数（随配置保存），"none" 清除已保存的跳板机，也不再继承模式的跳板机
+func applyProxyJump(cfg *config.SSHConfig, proxyJump string) {
+	switch proxyJump {
+	case "":
+		return
+	case "none":
+		cfg.ProxyJump = ""
+	default:
+		cfg.ProxyJump = proxyJump
 	}
+	cfg.TrackOverride("proxy_jump")
+}
 
-	// 通过跳板机连接到目标服务器
-	targetAddr := cfg.Host + ":" + cfg.Port
-	fmt.Printf("Connecting to target host %s through jump host...\n", targetAddr)
-	targetConn, err := jumpClient.Dial("tcp", targetAddr)
-	if err != nil {
-		jumpClient.Close()
-		return nil, fmt.Errorf("failed to dial target through jump host: %v", err)
+// displayConfigs 按分组列出 selectConfigs 选出的主机，tags 为 --tag 指定的标签。
+// 模式不是主机，用 ssm config pattern list 查看
+func displayConfigs(configs []config.SSHConfig, tags []string) {
+	if len(configs) == 0 {
+		if len(tags) > 0 {
+			fmt.Printf("No SSH connection configurations tagged %s found.\n", strings.Join(tags, ", "))
+			return
+		}
+		fmt.Println("No SSH connection configurations found.")
+		return
 	}
 
-	// 建立SSH连接
-	ncc, chans, reqs, err := ssh.NewClientConn(targetConn, targetAddr, clientConfig)
-	if err != nil {
-		targetConn.Close()
-		jumpClient.Close()
-		return nil, fmt.Errorf("failed to establish SSH connection: %v", err)
+	// 分组按名称排序，未分组的主机在最后；组内保持 --sort 指定的顺序（默认为最后使用时间）
+	groups := make(map[string][]config.SSHConfig)
+	var names []string
+	for _, cfg := range configs {
+		if _, exists := groups[cfg.Group]; !exists {
+			names = append(names, cfg.Group)
+		}
+		groups[cfg.Group] = append(groups[cfg.Group], cfg)
 	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "" || names[j] == "" {
+			return names[j] == ""
+		}
+		return names[i] < names[j]
+	})
 
-	return ssh.NewClient(ncc, chans, reqs), nil
-}
-
-func parseJumpConfig(proxyJump string) *config.SSHConfig {
-	username, hostname, port := utils.ParseSSHHost(proxyJump)
-	key := utils.GetConfigKey(username, hostname, port)
-	// 先尝试从保存的配置中查找
-	if jumpConfig, exists := config.Get(key); exists {
-		return jumpConfig
-	}
+	fmt.Println("SSH Connection Configurations:")
+	i := 0
+	for _, name := range names {
+		indent := ""
+		if len(names) > 1 || name != "" {
+			indent = "  "
+			if name == "" {
+				fmt.Println("(no group)")
+			} else {
+				fmt.Printf("%s:\n", name)
+			}
+		}
 
-	return &config.SSHConfig{
-		Host:     hostname,
-		Username: username,
-		Port:     port,
+		for _, cfg := range groups[name] {
+			i++
+			line := fmt.Sprintf("%s%d. %s", indent, i, cfg.GetKey())
+			if cfg.Alias != "" {
+				line += fmt.Sprintf(" (%s)", cfg.Alias)
+			}
+			if cfg.ProxyJump != "" {
+				line += fmt.Sprintf(" via %s", cfg.ProxyJump)
+			}
+			if len(cfg.Tags) > 0 {
+				line += fmt.Sprintf(" [%s]", strings.Join(cfg.Tags, ", "))
+			}
+			fmt.Println(line)
+		}
 	}
 }
 
-func displayConfigs() {
-	configs, err := config.List()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to load configs: %v\n", err)
-		return
-	}
-
-	if len(configs) == 0 {
-		fmt.Println("No SSH connection configurations found.")
-		return
+func (a *app) handleDelete(key string) {
+	// 也可以按别名删除
+	sshConfig, exists := config.GetByAlias(a.store, key)
+	if exists {
+		key = sshConfig.GetKey()
+	} else {
+		sshConfig, exists = a.store.Get(key)
 	}
 
-	fmt.Println("SSH Connection Configurations:")
-	for i, cfg := range configs {
-		line := fmt.Sprintf("%d. %s", i+1, cfg.GetKey())
-		if cfg.ProxyJump != "" {
-			line += fmt.Sprintf(" via %s", cfg.ProxyJump)
+	// 先删除保险库中的密码，避免留下无人引用的条目
+	if exists {
+		if err := a.auth.ForgetPassword(sshConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to delete saved password: %v\n", err)
+			os.Exit(1)
 		}
-		fmt.Println(line)
 	}
-}
 
-func handleDelete(key string) {
-	if err := config.Delete(key); err != nil {
+	if err := a.store.Delete(key); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to delete config: %v\n", err)
 		os.Exit(1)
 	}
 	fmt.Printf("Successfully deleted connection config: %s\n", key)
 }
+
+// pluralize 数量加名词，如 "1 host"、"3 hosts"、"2 entries"
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	if stem, ok := strings.CutSuffix(noun, "y"); ok && stem != "" && !strings.ContainsAny(stem[len(stem)-1:], "aeiou") {
+		return fmt.Sprintf("%d %sies", count, stem)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,54 @@
+// cmd/root_test.go
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+func TestApplyForwardAgent(t *testing.T) {
+	if flag := rootCmd.Flags().ShorthandLookup("A"); flag == nil || flag.Name != "forward-agent" {
+		t.Fatal("-A is not the shorthand of --forward-agent")
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		saved bool
+		want  bool
+	}{
+		{"keep saved setting", nil, true, true},
+		{"enable", []string{"-A"}, false, true},
+		{"disable", []string{"--forward-agent=false"}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t, config.SSHConfig{Host: "10.0.0.1", Username: "root", Port: "22", ForwardAgent: tt.saved})
+			cmd := &cobra.Command{}
+			cmd.Flags().BoolP("forward-agent", "A", false, "")
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			sshConfig, _ := a.store.Get("root@10.0.0.1:22")
+			applyForwardAgent(cmd, sshConfig)
+			if err := a.store.Put(sshConfig); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+
+			saved, _ := a.store.Get("root@10.0.0.1:22")
+			if saved.ForwardAgent != tt.want {
+				t.Errorf("saved ForwardAgent = %v, want %v", saved.ForwardAgent, tt.want)
+			}
+			data, _ := json.Marshal(saved)
+			if got := strings.Contains(string(data), `"forward_agent":true`); got != tt.want {
+				t.Errorf("saved config %s, want forward_agent = %v", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// cmd/tag.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Manage host tags",
+	Long: `Manage host tags. Tags select groups of hosts, for example with
+'ssm --list --tag prod' or 'ssm exec --tag web -- uptime'. When several tags are
+given, a host must have all of them.
+
+Examples:
+  ssm tag add prod-db prod db        # Add tags to a host (alias or [user@]hostname[:port])
+  ssm tag rm prod-db db              # Remove a tag
+  ssm tag list                       # List tags and how many hosts have them
+  ssm --list --tag prod              # List hosts tagged prod`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add host tag...",
+	Short: "Add tags to a host (the connection is created if needed)",
+	Args:  cobra.MinimumNArgs(2),
+	Run:   runTagAdd,
+}
+
+var tagRmCmd = &cobra.Command{
+	Use:     "rm host tag...",
+	Aliases: []string{"remove"},
+	Short:   "Remove tags from a host",
+	Args:    cobra.MinimumNArgs(2),
+	Run:     runTagRm,
+}
+
+var tagListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List tags",
+	Args:    cobra.NoArgs,
+	Run:     runTagList,
+}
+
+func init() {
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRmCmd)
+	tagCmd.AddCommand(tagListCmd)
+
+	rootCmd.AddCommand(tagCmd)
+}
+
+func runTagAdd(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	tags := args[1:]
+	for _, tag := range tags {
+		if err := config.ValidateTag(tag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	sshConfig := a.getOrNewConfig(args[0])
+	sshConfig.AddTags(tags...)
+	if err := a.store.Put(sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: %s\n", sshConfig.GetKey(), strings.Join(sshConfig.Tags, ", "))
+}
+
+func runTagRm(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	username, hostname, port := a.resolveHostArg(args[0])
+	key := utils.GetConfigKey(username, hostname, port)
+	sshConfig, exists := a.store.Get(key)
+	if !exists {
+		fmt.Fprintf(os.Stderr, "Error: connection config not found: %s\n", key)
+		os.Exit(1)
+	}
+
+	removed := sshConfig.RemoveTags(args[1:]...)
+	if len(removed) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: %s has none of the tags %s\n", key, strings.Join(args[1:], ", "))
+		os.Exit(1)
+	}
+	if err := a.store.Put(sshConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed %s from %s\n", strings.Join(removed, ", "), key)
+}
+
+func runTagList(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	configs, err := a.store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configs: %v\n", err)
+		os.Exit(1)
+	}
+
+	counts := make(map[string]int)
+	for _, cfg := range configs {
+		for _, tag := range cfg.Tags {
+			counts[tag]++
+		}
+	}
+	if len(counts) == 0 {
+		fmt.Println("No tags found.")
+		return
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		fmt.Printf("%-20s %s\n", tag, pluralize(counts[tag], "host"))
+	}
+}
+
+// getOrNewConfig 获取主机（别名或 [user@]hostname[:port]）已保存的配置，没有时新建（未保存）
+func (a *app) getOrNewConfig(host string) *config.SSHConfig {
+	username, hostname, port := a.resolveHostArg(host)
+	if sshConfig, exists := a.store.Get(utils.GetConfigKey(username, hostname, port)); exists {
+		return sshConfig
+	}
+	return &config.SSHConfig{
+		Host:     hostname,
+		Username: username,
+		Port:     port,
+	}
+}
@@ -0,0 +1,286 @@
+// cmd/vault.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wuxs/ssm/pkg/auth"
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/vault"
+)
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Manage the encrypted credential vault",
+	Long: `Manage the encrypted credential vault (~/.ssm/vault.json).
+
+Passwords are encrypted with AES-256-GCM using a key derived from a master
+passphrase with scrypt. Once the vault is initialized, passwords captured on
+login are stored in the vault and the connection config only keeps a reference.
+
+Examples:
+  ssm vault init                 # Create the vault
+  ssm vault migrate              # Move plaintext passwords into the vault
+  ssm vault unlock -t 1h         # Unlock for one hour
+  ssm vault lock                 # Forget the unlock session
+  ssm vault rotate               # Change the master passphrase`,
+}
+
+var vaultInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the vault with a new master passphrase",
+	Args:  cobra.NoArgs,
+	Run:   runVaultInit,
+}
+
+var vaultUnlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock the vault for a period of time",
+	Args:  cobra.NoArgs,
+	Run:   runVaultUnlock,
+}
+
+var vaultLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the vault by removing the unlock session",
+	Args:  cobra.NoArgs,
+	Run:   runVaultLock,
+}
+
+var vaultRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Change the master passphrase and re-encrypt all items",
+	Args:  cobra.NoArgs,
+	Run:   runVaultRotate,
+}
+
+var vaultMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move plaintext passwords from the connection configs into the vault",
+	Args:  cobra.NoArgs,
+	Run:   runVaultMigrate,
+}
+
+var vaultStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the vault status",
+	Args:  cobra.NoArgs,
+	Run:   runVaultStatus,
+}
+
+func init() {
+	vaultUnlockCmd.Flags().DurationP("timeout", "t", 15*time.Minute, "How long the vault stays unlocked")
+
+	vaultCmd.AddCommand(vaultInitCmd)
+	vaultCmd.AddCommand(vaultUnlockCmd)
+	vaultCmd.AddCommand(vaultLockCmd)
+	vaultCmd.AddCommand(vaultRotateCmd)
+	vaultCmd.AddCommand(vaultMigrateCmd)
+	vaultCmd.AddCommand(vaultStatusCmd)
+
+	rootCmd.AddCommand(vaultCmd)
+}
+
+func runVaultInit(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	path := a.auth.GetVaultPath()
+	if vault.Exists(path) {
+		fmt.Fprintf(os.Stderr, "Vault already exists: %s\n", path)
+		os.Exit(1)
+	}
+
+	if _, err := a.createVault(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create vault: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Vault created: %s\n", path)
+	fmt.Println("Run 'ssm vault migrate' to move existing plaintext passwords into the vault.")
+}
+
+func runVaultUnlock(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "Invalid timeout: %s\n", timeout)
+		os.Exit(1)
+	}
+
+	v, err := a.auth.UnlockVault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to unlock vault: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := v.SaveSession(timeout); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save unlock session: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Vault unlocked until %s\n", time.Now().Add(timeout).Format(time.RFC3339))
+}
+
+func runVaultLock(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	v, err := vault.Open(a.auth.GetVaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open vault: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := v.ClearSession(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to lock vault: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Vault locked.")
+}
+
+func runVaultRotate(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	v, err := a.auth.UnlockVault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to unlock vault: %v\n", err)
+		os.Exit(1)
+	}
+
+	passphrase, err := promptNewPassphrase("vault")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if err := v.Rotate(passphrase); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to rotate vault passphrase: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 旧的解锁会话使用旧密钥，更换主密码后一并清除
+	if err := v.ClearSession(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	fmt.Printf("Vault passphrase changed, %d items re-encrypted.\n", len(v.List()))
+}
+
+func runVaultMigrate(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	path := a.auth.GetVaultPath()
+	if !vault.Exists(path) {
+		fmt.Println("Vault does not exist yet, creating it.")
+		if _, err := a.createVault(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create vault: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	store, err := config.Snapshot(a.store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configs: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 解锁保险库需要交互，先完成加密再写入
+	sealed := make(map[string]config.SSHConfig)
+	for key, item := range store.Items {
+		if item.Password == "" {
+			continue
+		}
+
+		if err := a.auth.SealPassword(&item); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to migrate password of %s: %v\n", key, err)
+			os.Exit(1)
+		}
+		sealed[key] = item
+	}
+
+	if len(sealed) == 0 {
+		fmt.Println("No plaintext passwords found.")
+		return
+	}
+
+	// 只更新密码字段，保留其他进程在此期间对配置的修改
+	for key, item := range sealed {
+		current, exists := a.store.Get(key)
+		if !exists {
+			continue
+		}
+		current.Password = item.Password
+		current.PasswordRef = item.PasswordRef
+		if err := a.store.Put(current); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save config %s: %v\n", key, err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("Moved %d plaintext passwords into the vault.\n", len(sealed))
+}
+
+func runVaultStatus(cmd *cobra.Command, args []string) {
+	a := appFrom(cmd)
+	path := a.auth.GetVaultPath()
+	if !vault.Exists(path) {
+		fmt.Println("Vault: not initialized (run 'ssm vault init')")
+		return
+	}
+
+	v, err := vault.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open vault: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Vault: %s\n", path)
+	fmt.Printf("Items: %d\n", len(v.List()))
+	if expiresAt, err := v.UnlockWithSession(); err == nil {
+		fmt.Printf("Status: unlocked until %s\n", expiresAt.Format(time.RFC3339))
+	} else {
+		fmt.Println("Status: locked")
+	}
+
+	if configs, err := a.store.List(); err == nil {
+		plaintext := 0
+		for _, cfg := range configs {
+			if cfg.Password != "" {
+				plaintext++
+			}
+		}
+		if plaintext > 0 {
+			fmt.Printf("Warning: %d configs still hold plaintext passwords, run 'ssm vault migrate'\n", plaintext)
+		}
+	}
+}
+
+// createVault 提示输入新的主密码并创建保险库
+func (a *app) createVault(path string) (*vault.Vault, error) {
+	passphrase, err := promptNewPassphrase("vault")
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := vault.Create(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	a.auth.UseVault(v)
+	return v, nil
+}
+
+// promptNewPassphrase 提示输入两次新的口令，kind 为口令的用途，如 vault
+func promptNewPassphrase(kind string) (string, error) {
+	passphrase, err := auth.PromptPassword(fmt.Sprintf("New %s passphrase: ", kind))
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("%s passphrase must not be empty", kind)
+	}
+
+	confirm, err := auth.PromptPassword(fmt.Sprintf("Confirm %s passphrase: ", kind))
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	if passphrase != confirm {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
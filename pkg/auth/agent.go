@@ -0,0 +1,93 @@
+// pkg/auth/agent.go
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	agentOnce   sync.Once
+	agentClient agent.ExtendedAgent
+	agentErr    error
+)
+
+// GetAgent 连接 SSH_AUTH_SOCK 指向的 ssh-agent，整个进程共享同一个连接
+func GetAgent() (agent.ExtendedAgent, error) {
+	agentOnce.Do(func() {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			agentErr = fmt.Errorf("SSH_AUTH_SOCK is not set")
+			return
+		}
+
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			agentErr = fmt.Errorf("failed to connect to ssh-agent: %v", err)
+			return
+		}
+		agentClient = agent.NewClient(conn)
+	})
+	return agentClient, agentErr
+}
+
+// ForwardAgent 为会话开启 agent 转发，远程主机上的认证请求转发到本地 SSH_AUTH_SOCK
+// client 为目标主机的连接，经跳板机建立的连接同样适用
+func ForwardAgent(client *ssh.Client, session *ssh.Session) error {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return fmt.Errorf("SSH_AUTH_SOCK is not set, no ssh-agent to forward")
+	}
+
+	if err := agent.ForwardToRemote(client, socket); err != nil {
+		return fmt.Errorf("failed to forward ssh-agent: %v", err)
+	}
+	if err := agent.RequestAgentForwarding(session); err != nil {
+		return fmt.Errorf("failed to request agent forwarding: %v", err)
+	}
+	return nil
+}
+
+// getAgentSigners 获取 ssh-agent 中的签名器，agent 不可用时返回空
+func getAgentSigners() []ssh.Signer {
+	sshAgent, err := GetAgent()
+	if err != nil {
+		return nil
+	}
+
+	signers, err := sshAgent.Signers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to list ssh-agent keys: %v\n", err)
+		return nil
+	}
+	for _, signer := range signers {
+		recordKeySource(signer.PublicKey(), "ssh-agent")
+	}
+	return signers
+}
+
+// findSignerByPublicKey 在签名器列表中查找与公钥匹配的签名器
+func findSignerByPublicKey(signers []ssh.Signer, publicKey ssh.PublicKey) ssh.Signer {
+	for _, signer := range signers {
+		if bytes.Equal(signer.PublicKey().Marshal(), publicKey.Marshal()) {
+			return signer
+		}
+	}
+	return nil
+}
+
+// appendUniqueSigners 追加签名器，跳过公钥重复的项
+func appendUniqueSigners(signers []ssh.Signer, more ...ssh.Signer) []ssh.Signer {
+	for _, signer := range more {
+		if findSignerByPublicKey(signers, signer.PublicKey()) == nil {
+			signers = append(signers, signer)
+		}
+	}
+	return signers
+}
@@ -0,0 +1,252 @@
+// pkg/auth/agent_test.go
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+// writeTestKey 生成 ed25519 私钥写入 dir/name，withPub 时同时写入 .pub 文件
+func writeTestKey(t *testing.T, dir, name string, withPub bool) (string, ed25519.PrivateKey) {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if withPub {
+		signer, _ := ssh.NewSignerFromKey(private)
+		if err := os.WriteFile(path+".pub", ssh.MarshalAuthorizedKey(signer.PublicKey()), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path, private
+}
+
+// useTestAgent 启动内存中的 ssh-agent 并通过 SSH_AUTH_SOCK 使用，测试结束后恢复
+func useTestAgent(t *testing.T, keys ...ed25519.PrivateKey) {
+	t.Helper()
+	keyring := agent.NewKeyring()
+	for _, key := range keys {
+		if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets are not available: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+
+	t.Setenv("SSH_AUTH_SOCK", socket)
+	agentOnce, agentClient, agentErr = sync.Once{}, nil, nil
+	t.Cleanup(func() {
+		listener.Close()
+		agentOnce, agentClient, agentErr = sync.Once{}, nil, nil
+	})
+}
+
+func publicKeys(signers []ssh.Signer) []string {
+	var keys []string
+	for _, signer := range signers {
+		keys = append(keys, ssh.FingerprintSHA256(signer.PublicKey()))
+	}
+	return keys
+}
+
+func fingerprintOf(t *testing.T, key ed25519.PrivateKey) string {
+	t.Helper()
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ssh.FingerprintSHA256(signer.PublicKey())
+}
+
+func TestGetAvailableSigners(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	inAgent, agentKey := writeTestKey(t, home, "in_agent", true)
+	_, otherAgentKey := writeTestKey(t, home, "other", false)
+	fileOnly, fileKey := writeTestKey(t, home, "file_only", false)
+	useTestAgent(t, otherAgentKey, agentKey)
+
+	tests := []struct {
+		name string
+		cfg  config.SSHConfig
+		want []string
+	}{
+		{"agent keys before the key file", config.SSHConfig{PrivateKey: fileOnly},
+			[]string{fingerprintOf(t, otherAgentKey), fingerprintOf(t, agentKey), fingerprintOf(t, fileKey)}},
+		{"key file already in the agent", config.SSHConfig{PrivateKey: inAgent},
+			[]string{fingerprintOf(t, otherAgentKey), fingerprintOf(t, agentKey)}},
+		{"identities only, key loaded in the agent", config.SSHConfig{PrivateKey: inAgent, IdentitiesOnly: true},
+			[]string{fingerprintOf(t, agentKey)}},
+		{"identities only, key file", config.SSHConfig{PrivateKey: fileOnly, IdentitiesOnly: true},
+			[]string{fingerprintOf(t, fileKey)}},
+		{"identities only, missing key", config.SSHConfig{PrivateKey: filepath.Join(home, "missing"), IdentitiesOnly: true},
+			nil},
+	}
+	for _, tt := range tests {
+		got := publicKeys(getAvailableSigners(&tt.cfg))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: signers = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: signers = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSelectIdentityPrefersAgent(t *testing.T) {
+	dir := t.TempDir()
+	path, key := writeTestKey(t, dir, "id_ed25519", true)
+	agentSigner, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := selectIdentity(path, []ssh.Signer{agentSigner}); got != agentSigner {
+		t.Errorf("selectIdentity() = %v, want the ssh-agent signer", got)
+	}
+	if got := selectIdentity(path, nil); got == nil || ssh.FingerprintSHA256(got.PublicKey()) != fingerprintOf(t, key) {
+		t.Errorf("selectIdentity() without agent = %v, want the key file", got)
+	}
+}
+
+func TestGetAgentWithoutSocket(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	agentOnce, agentClient, agentErr = sync.Once{}, nil, nil
+	t.Cleanup(func() { agentOnce, agentClient, agentErr = sync.Once{}, nil, nil })
+
+	if _, err := GetAgent(); err == nil {
+		t.Error("GetAgent() without SSH_AUTH_SOCK expected an error")
+	}
+	if signers := getAgentSigners(); signers != nil {
+		t.Errorf("getAgentSigners() = %v, want none", signers)
+	}
+}
+
+func TestForwardAgent(t *testing.T) {
+	_, key := writeTestKey(t, t.TempDir(), "id_ed25519", false)
+	useTestAgent(t, key)
+
+	_, hostPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, _ := ssh.NewSignerFromKey(hostPrivate)
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(hostKey)
+
+	// 服务端收到 agent 转发请求后，通过转发的 agent 列出客户端的密钥
+	forwarded := make(chan []*agent.Key, 1)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		serverConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn, chans, reqs, err := ssh.NewServerConn(serverConn, serverConfig)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+		for newChannel := range chans {
+			_, requests, err := newChannel.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				for req := range requests {
+					if req.Type != "auth-agent-req@openssh.com" {
+						req.Reply(false, nil)
+						continue
+					}
+					req.Reply(true, nil)
+
+					channel, agentReqs, err := conn.OpenChannel("auth-agent@openssh.com", nil)
+					if err != nil {
+						forwarded <- nil
+						continue
+					}
+					go ssh.DiscardRequests(agentReqs)
+					keys, _ := agent.NewClient(channel).List()
+					channel.Close()
+					forwarded <- keys
+				}
+			}()
+		}
+	}()
+
+	client, err := ssh.Dial("tcp", listener.Addr().String(), &ssh.ClientConfig{
+		User:            "root",
+		HostKeyCallback: ssh.FixedHostKey(hostKey.PublicKey()),
+	})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	defer session.Close()
+
+	if err := ForwardAgent(client, session); err != nil {
+		t.Fatalf("ForwardAgent() error = %v", err)
+	}
+	select {
+	case keys := <-forwarded:
+		signer, _ := ssh.NewSignerFromKey(key)
+		if len(keys) != 1 || !bytes.Equal(keys[0].Blob, signer.PublicKey().Marshal()) {
+			t.Errorf("forwarded agent keys = %v, want the local agent key", keys)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent forwarding was not requested on the session")
+	}
+
+	t.Setenv("SSH_AUTH_SOCK", "")
+	if err := ForwardAgent(client, session); err == nil {
+		t.Error("ForwardAgent() without SSH_AUTH_SOCK succeeded")
+	}
+}
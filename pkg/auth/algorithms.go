@@ -0,0 +1,140 @@
+// pkg/auth/algorithms.go
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// GetKnownAlgorithms 获取 ssm 支持的全部算法（包括存在安全问题、仅用于兼容老旧设备的算法）
+func GetKnownAlgorithms() ssh.Algorithms {
+	supported := ssh.SupportedAlgorithms()
+	insecure := ssh.InsecureAlgorithms()
+	return ssh.Algorithms{
+		KeyExchanges:   append(supported.KeyExchanges, insecure.KeyExchanges...),
+		Ciphers:        append(supported.Ciphers, insecure.Ciphers...),
+		MACs:           append(supported.MACs, insecure.MACs...),
+		HostKeys:       append(supported.HostKeys, insecure.HostKeys...),
+		PublicKeyAuths: append(supported.PublicKeyAuths, insecure.PublicKeyAuths...),
+	}
+}
+
+// applyAlgorithms 将配置中的算法列表应用到客户端配置
+func applyAlgorithms(clientConfig *ssh.ClientConfig, cfg *config.SSHConfig) error {
+	supported := ssh.SupportedAlgorithms()
+	known := GetKnownAlgorithms()
+
+	var err error
+	if clientConfig.Ciphers, err = resolveAlgorithms("cipher", cfg.Ciphers, supported.Ciphers, known.Ciphers); err != nil {
+		return err
+	}
+	if clientConfig.MACs, err = resolveAlgorithms("MAC", cfg.MACs, supported.MACs, known.MACs); err != nil {
+		return err
+	}
+	if clientConfig.KeyExchanges, err = resolveAlgorithms("key exchange", cfg.KeyExchanges, supported.KeyExchanges, known.KeyExchanges); err != nil {
+		return err
+	}
+
+	// 主机密钥算法默认优先 known_hosts 中记录的类型
+	hostKeyDefaults := supported.HostKeys
+	if len(clientConfig.HostKeyAlgorithms) > 0 {
+		hostKeyDefaults = clientConfig.HostKeyAlgorithms
+	}
+	hostKeyAlgorithms, err := resolveAlgorithms("host key algorithm", cfg.HostKeyAlgorithms, hostKeyDefaults, known.HostKeys)
+	if err != nil {
+		return err
+	}
+	if hostKeyAlgorithms != nil {
+		clientConfig.HostKeyAlgorithms = hostKeyAlgorithms
+	}
+	return nil
+}
+
+// resolveAlgorithms 解析 OpenSSH 风格的算法列表，spec 为空时返回 nil（使用默认值）
+//
+//	"a,b"   替换默认列表
+//	"+a,b"  追加到默认列表之后
+//	"-a,b"  从默认列表中移除（支持 * 通配）
+//	"^a,b"  放到默认列表之前
+func resolveAlgorithms(kind, spec string, defaults, known []string) ([]string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	modifier := spec[0]
+	if modifier == '+' || modifier == '-' || modifier == '^' {
+		spec = spec[1:]
+	} else {
+		modifier = 0
+	}
+
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if modifier != '-' && !slices.Contains(known, name) {
+			return nil, fmt.Errorf("unsupported %s: %s", kind, name)
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("empty %s list", kind)
+	}
+
+	var result []string
+	switch modifier {
+	case '+':
+		result = appendMissing(slices.Clone(defaults), names...)
+	case '^':
+		result = appendMissing(names, defaults...)
+	case '-':
+		for _, algo := range defaults {
+			if !matchesAnyPattern(algo, names) {
+				result = append(result, algo)
+			}
+		}
+	default:
+		result = appendMissing(nil, names...)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no %s left after applying %q", kind, spec)
+	}
+	return result, nil
+}
+
+// appendMissing 追加列表中尚未包含的算法
+func appendMissing(list []string, names ...string) []string {
+	for _, name := range names {
+		if !slices.Contains(list, name) {
+			list = append(list, name)
+		}
+	}
+	return list
+}
+
+// matchesAnyPattern 检查算法是否匹配任一模式（支持末尾 * 通配）
+func matchesAnyPattern(algo string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(algo, prefix) {
+				return true
+			}
+		} else if algo == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAlgorithms 检查配置中的算法列表能否解析，不连接主机
+func ValidateAlgorithms(cfg *config.SSHConfig) error {
+	return applyAlgorithms(&ssh.ClientConfig{}, cfg)
+}
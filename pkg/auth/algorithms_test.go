@@ -0,0 +1,38 @@
+// pkg/auth/algorithms_test.go
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveAlgorithms(t *testing.T) {
+	defaults := []string{"a", "b", "c-1", "c-2"}
+	known := []string{"a", "b", "c-1", "c-2", "legacy"}
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty uses defaults", spec: "", want: nil},
+		{name: "replace", spec: "b,a", want: []string{"b", "a"}},
+		{name: "append", spec: "+legacy", want: []string{"a", "b", "c-1", "c-2", "legacy"}},
+		{name: "prepend", spec: "^legacy,b", want: []string{"legacy", "b", "a", "c-1", "c-2"}},
+		{name: "remove with wildcard", spec: "-c-*", want: []string{"a", "b"}},
+		{name: "unknown algorithm", spec: "unknown", wantErr: true},
+		{name: "remove everything", spec: "-*", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveAlgorithms("cipher", tt.spec, defaults, known)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveAlgorithms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveAlgorithms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,50 +2,158 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
-	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/vault"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/term"
 )
 
+// AuthManager 管理认证使用的本地状态：保存 ssm known_hosts 和保险库的目录，以及进程内已解锁的保险库
+type AuthManager struct {
+	configDir string
+
+	mu    sync.Mutex   // 保护 vault，同时保证同一时间只提示输入一次主密码
+	vault *vault.Vault // 已解锁的保险库，跳板机与目标主机共享
+}
+
+// NewAuthManager 创建认证管理器，configDir 为保存 ssm known_hosts 和保险库的目录
+func NewAuthManager(configDir string) *AuthManager {
+	return &AuthManager{configDir: configDir}
+}
+
+// ClientConfig SSH 客户端配置，附带本次连接的认证诊断记录
+type ClientConfig struct {
+	*ssh.ClientConfig
+	diag *authDiagnostics
+}
+
+// ExplainAuthError 认证失败时在错误信息后附加诊断信息，其他错误原样返回
+func (c *ClientConfig) ExplainAuthError(err error) error {
+	if err == nil || c.diag == nil || !strings.Contains(err.Error(), "unable to authenticate") {
+		return err
+	}
+	return fmt.Errorf("%v\n%s", err, c.diag)
+}
+
 // CreateClientConfig 创建SSH客户端配置，按照标准SSH认证顺序
-func CreateClientConfig(cfg *config.SSHConfig) (*ssh.ClientConfig, error) {
+func (m *AuthManager) CreateClientConfig(cfg *config.SSHConfig) (*ClientConfig, error) {
 	var authMethods []ssh.AuthMethod
+	diag := newAuthDiagnostics(cfg)
+
+	// 1. 尝试公钥认证（ssh-agent 与私钥文件）
+	signers := getAvailableSigners(cfg)
+	if len(signers) > 0 {
+		authMethods = append(authMethods, diag.publicKeys(signers))
+	}
 
-	// 1. 尝试公钥认证
-	if signers := getAvailableSigners(cfg.PrivateKey); len(signers) > 0 {
-		authMethods = append(authMethods, ssh.PublicKeys(signers...))
+	// 2. 如果有预设密码（明文或保险库引用），添加密码认证
+	if cfg.HasSavedPassword() {
+		diag.addMethod("password")
+		authMethods = append(authMethods, ssh.PasswordCallback(func() (string, error) {
+			diag.serverAdvertises("password")
+			return m.getSavedPassword(cfg)
+		}))
 	}
 
-	// 2. 如果有预设密码，添加密码认证
-	if cfg.Password != "" {
-		authMethods = append(authMethods, ssh.Password(cfg.Password))
-	} else {
-		// 3. 创建交互式密码认证
+	// 3. keyboard-interactive 认证（如 密码 + OTP 的二次验证）
+	challenge := m.keyboardInteractiveChallenge(cfg)
+	diag.addMethod("keyboard-interactive")
+	authMethods = append(authMethods, ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		diag.serverAdvertises("keyboard-interactive")
+		return challenge(name, instruction, questions, echos)
+	}))
+
+	if !cfg.HasSavedPassword() {
+		// 4. 创建交互式密码认证
+		diag.addMethod("password")
 		authMethods = append(authMethods, ssh.PasswordCallback(func() (string, error) {
-			password, err := PromptPassword(fmt.Sprintf("%s's password: ", cfg.Username))
+			diag.serverAdvertises("password")
+			password, err := readSecret(fmt.Sprintf("%s@%s's password: ", cfg.Username, cfg.Host))
 			cfg.Password = password
 			return password, err
 		}))
 	}
 
-	return &ssh.ClientConfig{
-		User:            cfg.Username,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 注意：生产环境中应使用更安全的验证方式
-	}, nil
+	// 没有可用密钥时仍记录服务端是否提供公钥认证
+	if len(signers) == 0 {
+		authMethods = append(authMethods, diag.probePublicKey())
+	}
+
+	return m.newClientConfig(cfg, diag, authMethods)
+}
+
+// CreatePublicKeyClientConfig 创建只使用指定密钥进行公钥认证的客户端配置
+func (m *AuthManager) CreatePublicKeyClientConfig(cfg *config.SSHConfig, signer ssh.Signer) (*ClientConfig, error) {
+	diag := newAuthDiagnostics(cfg)
+	return m.newClientConfig(cfg, diag, []ssh.AuthMethod{diag.publicKeys([]ssh.Signer{signer})})
+}
+
+// newClientConfig 使用认证方式创建客户端配置，并应用主机密钥校验和算法设置
+func (m *AuthManager) newClientConfig(cfg *config.SSHConfig, diag *authDiagnostics, authMethods []ssh.AuthMethod) (*ClientConfig, error) {
+	clientConfig := &ssh.ClientConfig{
+		User:              cfg.Username,
+		Auth:              authMethods,
+		HostKeyCallback:   m.NewHostKeyCallback(),
+		HostKeyAlgorithms: m.knownHostKeyAlgorithms(net.JoinHostPort(cfg.Host, cfg.Port)),
+		// 服务端登录前的提示信息（banner）输出到标准错误，不影响标准输出
+		BannerCallback: ssh.BannerDisplayStderr(),
+	}
+
+	// 按主机配置的算法（加密、MAC、密钥交换、主机密钥）
+	if err := applyAlgorithms(clientConfig, cfg); err != nil {
+		return nil, err
+	}
+
+	return &ClientConfig{ClientConfig: clientConfig, diag: diag}, nil
+}
+
+// getAvailableSigners 获取可用的签名器，ssh-agent 中的密钥优先于私钥文件
+func getAvailableSigners(cfg *config.SSHConfig) []ssh.Signer {
+	agentSigners := getAgentSigners()
+
+	// 只使用配置的单个身份，避免服务端 MaxAuthTries 过低时被拒绝
+	if cfg.IdentitiesOnly && cfg.PrivateKey != "" {
+		if signer := selectIdentity(cfg.PrivateKey, agentSigners); signer != nil {
+			return attachCertificates([]ssh.Signer{signer}, GetCertificateFiles(cfg))
+		}
+		return nil
+	}
+
+	signers := appendUniqueSigners(agentSigners, getFileSigners(cfg.PrivateKey)...)
+	return attachCertificates(signers, GetCertificateFiles(cfg))
+}
+
+// selectIdentity 选择与指定私钥对应的签名器，优先使用 ssh-agent 中已加载的同一密钥
+func selectIdentity(privateKeyPath string, agentSigners []ssh.Signer) ssh.Signer {
+	if publicKey, err := LoadPublicKey(privateKeyPath); err == nil {
+		if signer := findSignerByPublicKey(agentSigners, publicKey); signer != nil {
+			return signer
+		}
+	}
+
+	signer, err := LoadPrivateKey(privateKeyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load specified private key %s: %v\n", privateKeyPath, err)
+		return nil
+	}
+	return signer
 }
 
-// getAvailableSigners 获取可用的私钥签名器
-func getAvailableSigners(privateKeyPath string) []ssh.Signer {
+// getFileSigners 获取私钥文件中的签名器
+func getFileSigners(privateKeyPath string) []ssh.Signer {
 	var signers []ssh.Signer
 
 	// 如果指定了私钥路径，优先使用
 	if privateKeyPath != "" {
-		if signer, err := loadPrivateKey(privateKeyPath); err == nil {
+		if signer, err := LoadPrivateKey(privateKeyPath); err == nil {
 			signers = append(signers, signer)
 		} else {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to load specified private key %s: %v\n", privateKeyPath, err)
@@ -54,16 +162,9 @@ func getAvailableSigners(privateKeyPath string) []ssh.Signer {
 	}
 
 	// 尝试常见的默认私钥位置
-	defaultKeys := []string{
-		filepath.Join(os.Getenv("HOME"), ".ssh", "id_rsa"),
-		filepath.Join(os.Getenv("HOME"), ".ssh", "id_ed25519"),
-		filepath.Join(os.Getenv("HOME"), ".ssh", "id_ecdsa"),
-		filepath.Join(os.Getenv("HOME"), ".ssh", "id_dsa"),
-	}
-
-	for _, keyPath := range defaultKeys {
+	for _, keyPath := range getDefaultKeyPaths() {
 		if fileExists(keyPath) {
-			if signer, err := loadPrivateKey(keyPath); err == nil {
+			if signer, err := LoadPrivateKey(keyPath); err == nil {
 				signers = append(signers, signer)
 			}
 		}
@@ -72,8 +173,23 @@ func getAvailableSigners(privateKeyPath string) []ssh.Signer {
 	return signers
 }
 
-// loadPrivateKey 加载私钥
-func loadPrivateKey(privateKeyPath string) (ssh.Signer, error) {
+// LoadPublicKey 加载私钥对应的公钥文件（<私钥>.pub）
+func LoadPublicKey(privateKeyPath string) (ssh.PublicKey, error) {
+	data, err := os.ReadFile(privateKeyPath + ".pub")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %v", err)
+	}
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %v", err)
+	}
+
+	return publicKey, nil
+}
+
+// LoadPrivateKey 加载私钥
+func LoadPrivateKey(privateKeyPath string) (ssh.Signer, error) {
 	key, err := os.ReadFile(privateKeyPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read private key: %v", err)
@@ -81,12 +197,41 @@ func loadPrivateKey(privateKeyPath string) (ssh.Signer, error) {
 
 	signer, err := ssh.ParsePrivateKey(key)
 	if err != nil {
+		// 受密码保护的私钥，延迟到需要签名时再提示输入密码
+		var missingErr *ssh.PassphraseMissingError
+		if errors.As(err, &missingErr) {
+			return newEncryptedKeySigner(privateKeyPath, key, missingErr.PublicKey)
+		}
 		return nil, fmt.Errorf("failed to parse private key: %v", err)
 	}
 
+	recordKeySource(signer.PublicKey(), privateKeyPath)
 	return signer, nil
 }
 
+// promptSecret 和 promptLine 是认证过程中读取终端输入的函数，测试时替换
+var (
+	promptSecret = PromptPassword
+	promptLine   = PromptLine
+)
+
+// terminalMu 串行化终端交互，并行连接多台主机时提示不会交错、输入不会被其他连接读走
+var terminalMu sync.Mutex
+
+// readSecret 持有终端锁时提示输入密码
+func readSecret(prompt string) (string, error) {
+	terminalMu.Lock()
+	defer terminalMu.Unlock()
+	return promptSecret(prompt)
+}
+
+// readLine 持有终端锁时提示输入一行文本
+func readLine(prompt string) (string, error) {
+	terminalMu.Lock()
+	defer terminalMu.Unlock()
+	return promptLine(prompt)
+}
+
 // PromptPassword 安全地提示用户输入密码
 func PromptPassword(prompt string) (string, error) {
 	fmt.Print(prompt)
@@ -98,6 +243,30 @@ func PromptPassword(prompt string) (string, error) {
 	return string(passwordBytes), nil
 }
 
+// PromptLine 提示用户输入一行文本（回显）
+func PromptLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		// 逐字节读取，避免缓冲吞掉后续的终端输入
+		n, err := os.Stdin.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				break
+			}
+			return "", err
+		}
+	}
+	return strings.TrimRight(string(line), "\r"), nil
+}
+
 // fileExists 检查文件是否存在
 func fileExists(filename string) bool {
 	_, err := os.Stat(filename)
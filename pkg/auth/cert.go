@@ -0,0 +1,125 @@
+// pkg/auth/cert.go
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// GetCertificatePath 获取私钥旁的证书路径（<私钥>-cert.pub）
+func GetCertificatePath(privateKeyPath string) string {
+	return privateKeyPath + "-cert.pub"
+}
+
+// GetCertificateFiles 获取配置可用的证书文件：显式配置的证书、私钥旁的证书以及默认私钥旁的证书
+func GetCertificateFiles(cfg *config.SSHConfig) []string {
+	var candidates []string
+	if cfg.Certificate != "" {
+		candidates = append(candidates, cfg.Certificate)
+	}
+	if cfg.PrivateKey != "" {
+		candidates = append(candidates, GetCertificatePath(cfg.PrivateKey))
+	}
+	if !cfg.IdentitiesOnly {
+		for _, keyPath := range getDefaultKeyPaths() {
+			candidates = append(candidates, GetCertificatePath(keyPath))
+		}
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, file := range candidates {
+		if seen[file] || !fileExists(file) {
+			continue
+		}
+		seen[file] = true
+		files = append(files, file)
+	}
+	return files
+}
+
+// LoadCertificate 加载 OpenSSH 用户证书
+func LoadCertificate(certPath string) (*ssh.Certificate, error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %v", err)
+	}
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+
+	cert, ok := publicKey.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an OpenSSH certificate", certPath)
+	}
+	return cert, nil
+}
+
+// CheckCertificateValidity 检查证书在指定时间是否处于有效期内
+func CheckCertificateValidity(cert *ssh.Certificate, now time.Time) error {
+	unixNow := now.Unix()
+	if after := int64(cert.ValidAfter); unixNow < after {
+		return fmt.Errorf("certificate is not valid until %s", time.Unix(after, 0).Format(time.RFC3339))
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		if before := int64(cert.ValidBefore); before < 0 || unixNow >= before {
+			return fmt.Errorf("certificate expired at %s", time.Unix(before, 0).Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// attachCertificates 为匹配证书公钥的签名器附加证书
+// 证书签名器排在对应的原始签名器之前，过期或尚未生效的证书会给出警告并跳过
+func attachCertificates(signers []ssh.Signer, certFiles []string) []ssh.Signer {
+	var certSigners []ssh.Signer
+	for _, certPath := range certFiles {
+		cert, err := LoadCertificate(certPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to load certificate %s: %v\n", certPath, err)
+			continue
+		}
+
+		if cert.CertType != ssh.UserCert {
+			fmt.Fprintf(os.Stderr, "Warning: Certificate %s is not a user certificate, skipping\n", certPath)
+			continue
+		}
+
+		if err := CheckCertificateValidity(cert, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Certificate %s (key ID %q): %v, skipping\n", certPath, cert.KeyId, err)
+			continue
+		}
+
+		signer := findSignerByPublicKey(signers, cert.Key)
+		if signer == nil {
+			continue
+		}
+
+		certSigner, err := ssh.NewCertSigner(cert, signer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to use certificate %s: %v\n", certPath, err)
+			continue
+		}
+		recordKeySource(cert, certPath)
+		certSigners = append(certSigners, certSigner)
+	}
+
+	return appendUniqueSigners(certSigners, signers...)
+}
+
+// getDefaultKeyPaths 获取常见的默认私钥位置
+func getDefaultKeyPaths() []string {
+	return []string{
+		filepath.Join(os.Getenv("HOME"), ".ssh", "id_rsa"),
+		filepath.Join(os.Getenv("HOME"), ".ssh", "id_ed25519"),
+		filepath.Join(os.Getenv("HOME"), ".ssh", "id_ecdsa"),
+		filepath.Join(os.Getenv("HOME"), ".ssh", "id_dsa"),
+	}
+}
@@ -0,0 +1,53 @@
+// pkg/auth/cert_test.go
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCheckCertificateValidity(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name    string
+		after   uint64
+		before  uint64
+		wantErr bool
+	}{
+		{
+			name:    "forever",
+			after:   0,
+			before:  ssh.CertTimeInfinity,
+			wantErr: false,
+		},
+		{
+			name:    "within validity",
+			after:   uint64(now.Add(-time.Hour).Unix()),
+			before:  uint64(now.Add(time.Hour).Unix()),
+			wantErr: false,
+		},
+		{
+			name:    "expired",
+			after:   uint64(now.Add(-2 * time.Hour).Unix()),
+			before:  uint64(now.Add(-time.Hour).Unix()),
+			wantErr: true,
+		},
+		{
+			name:    "not yet valid",
+			after:   uint64(now.Add(time.Hour).Unix()),
+			before:  ssh.CertTimeInfinity,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := &ssh.Certificate{ValidAfter: tt.after, ValidBefore: tt.before}
+			err := CheckCertificateValidity(cert, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckCertificateValidity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
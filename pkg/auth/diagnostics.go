@@ -0,0 +1,126 @@
+// pkg/auth/diagnostics.go
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// authDiagnostics 记录一次认证过程，认证失败时用于输出诊断信息
+//
+// 客户端只会调用服务端在认证失败消息中列出的认证方式，因此某个认证方式的回调被调用，
+// 即说明服务端列出了该方式；没有可用密钥时额外添加一个不发送任何请求的公钥认证，
+// 用于记录服务端是否提供公钥认证。
+type authDiagnostics struct {
+	mu         sync.Mutex
+	target     string
+	methods    []string     // ssm 配置的认证方式
+	advertised []string     // 服务端列出的认证方式
+	keys       []ssh.Signer // 可用于公钥认证的密钥
+}
+
+var keySources sync.Map // 公钥 -> 来源（ssh-agent 或文件路径）
+
+// newAuthDiagnostics 创建认证诊断记录
+func newAuthDiagnostics(cfg *config.SSHConfig) *authDiagnostics {
+	return &authDiagnostics{target: cfg.GetKey()}
+}
+
+// addMethod 记录 ssm 配置的认证方式
+func (d *authDiagnostics) addMethod(method string) {
+	if !slices.Contains(d.methods, method) {
+		d.methods = append(d.methods, method)
+	}
+}
+
+// serverAdvertises 记录服务端列出的认证方式
+func (d *authDiagnostics) serverAdvertises(method string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !slices.Contains(d.advertised, method) {
+		d.advertised = append(d.advertised, method)
+	}
+}
+
+// publicKeys 使用签名器进行公钥认证，并记录服务端是否提供公钥认证
+func (d *authDiagnostics) publicKeys(signers []ssh.Signer) ssh.AuthMethod {
+	d.keys = signers
+	d.addMethod("publickey")
+	return ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		d.serverAdvertises("publickey")
+		return signers, nil
+	})
+}
+
+// probePublicKey 不提供任何密钥的公钥认证，只记录服务端是否提供公钥认证
+func (d *authDiagnostics) probePublicKey() ssh.AuthMethod {
+	return ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		d.serverAdvertises("publickey")
+		return nil, nil
+	})
+}
+
+// String 格式化诊断信息
+func (d *authDiagnostics) String() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Authentication diagnostics for %s:\n", d.target)
+	fmt.Fprintf(&b, "  Methods available:     %s\n", formatList(d.methods))
+	fmt.Fprintf(&b, "  Offered by server:     %s\n", formatList(d.advertised))
+
+	var rejected []string
+	for _, method := range d.methods {
+		if !slices.Contains(d.advertised, method) {
+			rejected = append(rejected, method)
+		}
+	}
+	if len(rejected) > 0 {
+		fmt.Fprintf(&b, "  Not offered by server: %s\n", formatList(rejected))
+	}
+
+	switch {
+	case len(d.keys) == 0:
+		fmt.Fprintf(&b, "  Keys offered: none (no keys found in ssh-agent or ~/.ssh)\n")
+	case !slices.Contains(d.advertised, "publickey"):
+		fmt.Fprintf(&b, "  Keys offered: none (server does not offer publickey, %d keys available)\n", len(d.keys))
+	default:
+		fmt.Fprintf(&b, "  Keys offered:\n")
+		for _, signer := range d.keys {
+			fmt.Fprintf(&b, "    %s\n", describeKey(signer.PublicKey()))
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// recordKeySource 记录公钥的来源
+func recordKeySource(publicKey ssh.PublicKey, source string) {
+	keySources.LoadOrStore(string(publicKey.Marshal()), source)
+}
+
+// describeKey 描述公钥：类型、指纹和来源
+func describeKey(publicKey ssh.PublicKey) string {
+	desc := fmt.Sprintf("%s %s", publicKey.Type(), ssh.FingerprintSHA256(publicKey))
+	if cert, ok := publicKey.(*ssh.Certificate); ok {
+		desc = fmt.Sprintf("%s %s (certificate, key ID %q)", publicKey.Type(), ssh.FingerprintSHA256(cert.Key), cert.KeyId)
+	}
+	if source, ok := keySources.Load(string(publicKey.Marshal())); ok {
+		desc += fmt.Sprintf(" from %s", source)
+	}
+	return desc
+}
+
+// formatList 格式化列表，空列表显示为 none
+func formatList(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
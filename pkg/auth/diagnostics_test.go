@@ -0,0 +1,132 @@
+// pkg/auth/diagnostics_test.go
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+func TestExplainAuthError(t *testing.T) {
+	diag := &authDiagnostics{target: "root@example.com:22"}
+	diag.addMethod("publickey")
+	diag.addMethod("keyboard-interactive")
+	diag.addMethod("password")
+	diag.serverAdvertises("password")
+	clientConfig := &ClientConfig{ClientConfig: &ssh.ClientConfig{}, diag: diag}
+
+	authErr := errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none password], no supported methods remain")
+	got := clientConfig.ExplainAuthError(authErr).Error()
+	for _, want := range []string{
+		"Authentication diagnostics for root@example.com:22",
+		"Offered by server:     password",
+		"Not offered by server: publickey, keyboard-interactive",
+		"Keys offered: none",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ExplainAuthError() missing %q in:\n%s", want, got)
+		}
+	}
+
+	// 非认证错误原样返回
+	dialErr := errors.New("dial tcp: connection refused")
+	if got := clientConfig.ExplainAuthError(dialErr); got != dialErr {
+		t.Errorf("ExplainAuthError() = %v, want %v", got, dialErr)
+	}
+	if got := clientConfig.ExplainAuthError(nil); got != nil {
+		t.Errorf("ExplainAuthError(nil) = %v, want nil", got)
+	}
+}
+
+// startAuthServer 启动只接受 serverConfig 中认证方式（且总是拒绝）的 SSH 服务端，返回地址
+func startAuthServer(t *testing.T, serverConfig *ssh.ServerConfig) (string, ssh.PublicKey) {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				ssh.NewServerConn(conn, serverConfig)
+				conn.Close()
+			}()
+		}
+	}()
+	return listener.Addr().String(), hostKey.PublicKey()
+}
+
+func TestAuthDiagnosticsServerMethods(t *testing.T) {
+	reject := errors.New("rejected")
+	tests := []struct {
+		name         string
+		serverConfig *ssh.ServerConfig
+		offered      string
+		notOffered   string
+	}{
+		{
+			name: "password only",
+			serverConfig: &ssh.ServerConfig{
+				PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) { return nil, reject },
+			},
+			offered:    "Offered by server:     password",
+			notOffered: "Not offered by server: keyboard-interactive",
+		},
+		{
+			name: "publickey only",
+			serverConfig: &ssh.ServerConfig{
+				PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) { return nil, reject },
+			},
+			offered:    "Offered by server:     publickey",
+			notOffered: "Not offered by server: password, keyboard-interactive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, hostKey := startAuthServer(t, tt.serverConfig)
+			m, _, _ := useKnownHosts(t, knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey)+"\n")
+			useTestAgent(t)
+
+			host, port, _ := net.SplitHostPort(addr)
+			cfg := &config.SSHConfig{Host: host, Port: port, Username: "root", Password: "wrong"}
+			clientConfig, err := m.CreateClientConfig(cfg)
+			if err != nil {
+				t.Fatalf("CreateClientConfig() error = %v", err)
+			}
+
+			_, err = ssh.Dial("tcp", addr, clientConfig.ClientConfig)
+			if err == nil {
+				t.Fatal("Dial() succeeded, want authentication error")
+			}
+			got := clientConfig.ExplainAuthError(err).Error()
+			for _, want := range []string{tt.offered, tt.notOffered, "Keys offered: none (no keys found"} {
+				if !strings.Contains(got, want) {
+					t.Errorf("ExplainAuthError() missing %q in:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+// pkg/auth/hostkey.go
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/term"
+)
+
+// GetKnownHostsFiles 获取用于主机密钥校验的 known_hosts 文件列表
+// 第一个为 OpenSSH 的 ~/.ssh/known_hosts，第二个为 ssm 自己的 known_hosts（新主机写入此文件）
+func (m *AuthManager) GetKnownHostsFiles() []string {
+	return []string{
+		filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts"),
+		m.getSSMKnownHostsFile(),
+	}
+}
+
+// getSSMKnownHostsFile 获取 ssm 专用的 known_hosts 文件路径
+func (m *AuthManager) getSSMKnownHostsFile() string {
+	return filepath.Join(m.configDir, "known_hosts")
+}
+
+// NewHostKeyCallback 创建主机密钥校验回调
+// 已知主机按 known_hosts 校验，不匹配时拒绝连接；首次连接时显示指纹并询问用户是否信任
+func (m *AuthManager) NewHostKeyCallback() ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		callback, err := m.loadKnownHosts()
+		if err != nil {
+			return err
+		}
+
+		if callback != nil {
+			err := callback(hostname, remote, key)
+			if err == nil {
+				return nil
+			}
+
+			var keyErr *knownhosts.KeyError
+			if !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+				return hostKeyMismatchError(hostname, key, err)
+			}
+		}
+
+		// 未知主机，询问用户
+		return m.confirmUnknownHost(hostname, remote, key)
+	}
+}
+
+// loadKnownHosts 加载所有存在的 known_hosts 文件，没有任何文件时返回 nil
+func (m *AuthManager) loadKnownHosts() (ssh.HostKeyCallback, error) {
+	var files []string
+	for _, file := range m.GetKnownHostsFiles() {
+		if fileExists(file) {
+			files = append(files, file)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known hosts: %v", err)
+	}
+	return callback, nil
+}
+
+// hostKeyMismatchError 生成主机密钥不匹配（或已吊销）时的错误信息
+func hostKeyMismatchError(hostname string, key ssh.PublicKey, err error) error {
+	var revokedErr *knownhosts.RevokedError
+	if errors.As(err, &revokedErr) {
+		return fmt.Errorf("host key verification failed for %s: %s key %s is marked as revoked in %s:%d",
+			hostname, key.Type(), ssh.FingerprintSHA256(key), revokedErr.Revoked.Filename, revokedErr.Revoked.Line)
+	}
+
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return fmt.Errorf("host key verification failed for %s: %v", hostname, err)
+	}
+
+	var known []string
+	for _, want := range keyErr.Want {
+		known = append(known, fmt.Sprintf("  %s:%d (%s %s)", want.Filename, want.Line, want.Key.Type(), ssh.FingerprintSHA256(want.Key)))
+	}
+
+	return fmt.Errorf("host key verification failed for %s: REMOTE HOST IDENTIFICATION HAS CHANGED!\n"+
+		"The server presented %s key %s, which does not match the known keys:\n%s\n"+
+		"Someone could be eavesdropping on you right now (man-in-the-middle attack), or the host key has just been changed.\n"+
+		"If the change is expected, remove the offending entry and connect again",
+		hostname, key.Type(), ssh.FingerprintSHA256(key), strings.Join(known, "\n"))
+}
+
+// confirmUnknownHost 首次连接时显示指纹并询问用户，确认后写入 ssm 的 known_hosts
+func (m *AuthManager) confirmUnknownHost(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsFile := m.getSSMKnownHostsFile()
+	fingerprint := ssh.FingerprintSHA256(key)
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("host key verification failed: %s is not a known host (%s key %s) and no terminal is available to confirm it",
+			hostname, key.Type(), fingerprint)
+	}
+
+	// 指纹与确认作为一次完整的对话，期间不穿插其他连接的提示
+	terminalMu.Lock()
+	defer terminalMu.Unlock()
+
+	hostDisplay := hostname
+	if remote != nil && remote.String() != hostname {
+		hostDisplay = fmt.Sprintf("%s (%s)", hostname, remote.String())
+	}
+	fmt.Printf("The authenticity of host '%s' can't be established.\n", hostDisplay)
+	fmt.Printf("%s key fingerprint is %s.\n", key.Type(), fingerprint)
+
+	prompt := "Are you sure you want to continue connecting (yes/no)? "
+	for {
+		answer, err := PromptLine(prompt)
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %v", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "yes", "y":
+			if err := appendKnownHost(knownHostsFile, hostname, key); err != nil {
+				return err
+			}
+			fmt.Printf("Warning: Permanently added '%s' (%s) to the list of known hosts.\n", knownhosts.Normalize(hostname), key.Type())
+			return nil
+		case "no", "n":
+			return fmt.Errorf("host key verification failed: %s was not accepted", hostname)
+		default:
+			prompt = "Please type 'yes' or 'no': "
+		}
+	}
+}
+
+// appendKnownHost 以 OpenSSH 格式追加一条主机密钥记录
+func appendKnownHost(knownHostsFile, hostname string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(knownHostsFile), 0700); err != nil {
+		return fmt.Errorf("failed to create known hosts directory: %v", err)
+	}
+
+	f, err := os.OpenFile(knownHostsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open known hosts file: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintln(f, knownhosts.Line([]string{hostname}, key)); err != nil {
+		return fmt.Errorf("failed to write known hosts file: %v", err)
+	}
+	return nil
+}
+
+// knownHostKeyAlgorithms 返回 known_hosts 中记录的该主机的密钥算法
+// 使服务端优先选择已记录的密钥类型，避免因算法不同被误判为密钥变更；未知主机返回 nil
+func (m *AuthManager) knownHostKeyAlgorithms(hostname string) []string {
+	callback, err := m.loadKnownHosts()
+	if err != nil || callback == nil {
+		return nil
+	}
+
+	// 使用一个不可能匹配的探测密钥，从 KeyError 中取出已知密钥
+	err = callback(hostname, probeAddr{}, probeKey{})
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return nil
+	}
+
+	var algorithms []string
+	seen := make(map[string]bool)
+	for _, want := range keyErr.Want {
+		for _, algo := range algorithmsForKeyType(want.Key.Type()) {
+			if !seen[algo] {
+				seen[algo] = true
+				algorithms = append(algorithms, algo)
+			}
+		}
+	}
+	return algorithms
+}
+
+// algorithmsForKeyType 将密钥类型映射为可协商的主机密钥算法
+func algorithmsForKeyType(keyType string) []string {
+	switch keyType {
+	case ssh.KeyAlgoRSA:
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	case ssh.CertAlgoRSAv01:
+		return []string{ssh.CertAlgoRSASHA512v01, ssh.CertAlgoRSASHA256v01, ssh.CertAlgoRSAv01}
+	default:
+		return []string{keyType}
+	}
+}
+
+// probeAddr 探测用的远程地址
+type probeAddr struct{}
+
+func (probeAddr) Network() string { return "tcp" }
+func (probeAddr) String() string  { return "0.0.0.0:0" }
+
+// probeKey 探测用的公钥，不会与任何已知密钥匹配
+type probeKey struct{}
+
+func (probeKey) Type() string    { return "ssm-probe" }
+func (probeKey) Marshal() []byte { return []byte("ssm-probe") }
+func (probeKey) Verify(data []byte, sig *ssh.Signature) error {
+	return errors.New("probe key cannot verify signatures")
+}
@@ -0,0 +1,121 @@
+// pkg/auth/hostkey_test.go
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// useKnownHosts 测试期间使用临时的 ~/.ssh/known_hosts 和 ssm known_hosts，返回认证管理器和两个文件路径
+func useKnownHosts(t *testing.T, openssh string) (*AuthManager, string, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	m := NewAuthManager(filepath.Join(home, ".ssm"))
+
+	files := m.GetKnownHostsFiles()
+	if openssh != "" {
+		if err := os.MkdirAll(filepath.Dir(files[0]), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(files[0], []byte(openssh), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m, files[0], files[1]
+}
+
+func newHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+var testRemote = &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 22}
+
+func TestHostKeyCallbackKnownHosts(t *testing.T) {
+	known, other := newHostKey(t), newHostKey(t)
+	portKey := newHostKey(t)
+	revoked := newHostKey(t)
+
+	lines := []string{
+		knownhosts.Line([]string{"plain.example.com"}, known),
+		knownhosts.Line([]string{knownhosts.HashHostname("hashed.example.com")}, known),
+		knownhosts.Line([]string{knownhosts.Normalize("port.example.com:2222")}, portKey),
+		"@revoked * " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(revoked))),
+	}
+	m, _, _ := useKnownHosts(t, strings.Join(lines, "\n")+"\n")
+	callback := m.NewHostKeyCallback()
+
+	tests := []struct {
+		name     string
+		hostname string
+		key      ssh.PublicKey
+		wantErr  string
+	}{
+		{"known key", "plain.example.com:22", known, ""},
+		{"hashed host", "hashed.example.com:22", known, ""},
+		{"non-standard port", "port.example.com:2222", portKey, ""},
+		{"key mismatch", "plain.example.com:22", other, "REMOTE HOST IDENTIFICATION HAS CHANGED"},
+		{"hashed host mismatch", "hashed.example.com:22", other, "REMOTE HOST IDENTIFICATION HAS CHANGED"},
+		{"port is part of the host", "port.example.com:22", portKey, "not a known host"},
+		{"revoked key", "plain.example.com:22", revoked, "marked as revoked"},
+	}
+	for _, tt := range tests {
+		err := callback(tt.hostname, testRemote, tt.key)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: error = %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAppendKnownHost(t *testing.T) {
+	m, _, ssmFile := useKnownHosts(t, "")
+	key := newHostKey(t)
+	callback := m.NewHostKeyCallback()
+
+	// 没有终端时不能确认未知主机
+	if err := callback("new.example.com:2222", testRemote, key); err == nil || !strings.Contains(err.Error(), "not a known host") {
+		t.Fatalf("unknown host error = %v", err)
+	}
+
+	if err := appendKnownHost(ssmFile, "new.example.com:2222", key); err != nil {
+		t.Fatalf("appendKnownHost() error = %v", err)
+	}
+	data, err := os.ReadFile(ssmFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "[new.example.com]:2222 ") {
+		t.Errorf("known_hosts line = %q, want it to start with [new.example.com]:2222", data)
+	}
+
+	if err := callback("new.example.com:2222", testRemote, key); err != nil {
+		t.Errorf("accepted host error = %v", err)
+	}
+	if err := callback("new.example.com:2222", testRemote, newHostKey(t)); err == nil {
+		t.Error("another key for the accepted host expected an error")
+	}
+	if got := m.knownHostKeyAlgorithms("new.example.com:2222"); !reflect.DeepEqual(got, []string{ssh.KeyAlgoED25519}) {
+		t.Errorf("knownHostKeyAlgorithms() = %v, want [%s]", got, ssh.KeyAlgoED25519)
+	}
+}
@@ -0,0 +1,71 @@
+// pkg/auth/keyboard.go
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// keyboardInteractiveChallenge 创建 keyboard-interactive 认证回调
+// 显示服务端的名称与说明，按服务端要求回显或隐藏输入；单独的密码提示会复用/保存配置中的密码
+func (m *AuthManager) keyboardInteractiveChallenge(cfg *config.SSHConfig) ssh.KeyboardInteractiveChallenge {
+	savedPasswordUsed := false
+
+	return func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		if name != "" {
+			fmt.Println(name)
+		}
+		if instruction != "" {
+			fmt.Println(instruction)
+		}
+
+		answers := make([]string, len(questions))
+		for i, question := range questions {
+			echo := i < len(echos) && echos[i]
+			passwordPrompt := !echo && isPasswordPrompt(question)
+
+			// 已保存的密码只自动填写一次，错误时再提示用户输入
+			if passwordPrompt && cfg.HasSavedPassword() && !savedPasswordUsed {
+				savedPasswordUsed = true
+				if password, err := m.getSavedPassword(cfg); err == nil {
+					answers[i] = password
+					continue
+				}
+			}
+
+			// 与 OpenSSH 一致，在提示前标明用户和主机，便于区分跳板机和目标主机
+			prompt := fmt.Sprintf("(%s@%s) %s", cfg.Username, cfg.Host, question)
+
+			var answer string
+			var err error
+			if echo {
+				answer, err = readLine(prompt)
+			} else {
+				answer, err = readSecret(prompt)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if passwordPrompt {
+				cfg.Password = answer
+				savedPasswordUsed = true
+			}
+			answers[i] = answer
+		}
+
+		return answers, nil
+	}
+}
+
+// isPasswordPrompt 判断提示是否为密码输入（而非 OTP 等验证码）
+func isPasswordPrompt(question string) bool {
+	question = strings.ToLower(question)
+	return strings.Contains(question, "password") &&
+		!strings.Contains(question, "otp") &&
+		!strings.Contains(question, "one-time") &&
+		!strings.Contains(question, "code")
+}
@@ -0,0 +1,60 @@
+// pkg/auth/keyboard_test.go
+package auth
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+func TestIsPasswordPrompt(t *testing.T) {
+	tests := map[string]bool{
+		"Password: ":                    true,
+		"root@host's password:":         true,
+		"PASSWORD for LDAP:":            true,
+		"Verification code: ":           false,
+		"OTP password: ":                false,
+		"One-time password (OATH): ":    false,
+		"Enter your password and code:": false,
+		"Passcode: ":                    false,
+		"Token: ":                       false,
+	}
+	for question, want := range tests {
+		if got := isPasswordPrompt(question); got != want {
+			t.Errorf("isPasswordPrompt(%q) = %v, want %v", question, got, want)
+		}
+	}
+}
+
+func TestKeyboardInteractiveChallenge(t *testing.T) {
+	cfg := &config.SSHConfig{Host: "example.com", Username: "root", Port: "22", Password: "saved"}
+	prompts := usePrompts(t, "123456", "alice", "typed")
+	challenge := NewAuthManager(t.TempDir()).keyboardInteractiveChallenge(cfg)
+
+	// 保存的密码自动填写，验证码提示用户输入
+	answers, err := challenge("", "", []string{"Password: ", "Verification code: "}, []bool{false, false})
+	if err != nil {
+		t.Fatalf("challenge() error = %v", err)
+	}
+	if !reflect.DeepEqual(answers, []string{"saved", "123456"}) || *prompts != 1 {
+		t.Errorf("answers = %v after %d prompts, want [saved 123456] after 1", answers, *prompts)
+	}
+
+	// 回显的问题使用普通输入；保存的密码只自动填写一次，再次询问时由用户输入并保存
+	answers, err = challenge("Login", "Try again", []string{"Username: ", "Password: "}, []bool{true, false})
+	if err != nil {
+		t.Fatalf("challenge() error = %v", err)
+	}
+	if !reflect.DeepEqual(answers, []string{"alice", "typed"}) || *prompts != 3 {
+		t.Errorf("answers = %v after %d prompts, want [alice typed] after 3", answers, *prompts)
+	}
+	if cfg.Password != "typed" {
+		t.Errorf("Password = %q, want the typed password to be saved", cfg.Password)
+	}
+
+	// 没有问题时不提示
+	if answers, err := challenge("", "", nil, nil); err != nil || len(answers) != 0 {
+		t.Errorf("challenge() without questions = %v, %v", answers, err)
+	}
+}
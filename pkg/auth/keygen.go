@@ -0,0 +1,95 @@
+// pkg/auth/keygen.go
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// GenerateKey 生成 OpenSSH 格式的 ed25519 密钥对，私钥写入 privateKeyPath，公钥写入 <私钥>.pub
+// passphrase 为空时私钥不加密
+func GenerateKey(privateKeyPath, comment, passphrase string) (ssh.PublicKey, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %v", err)
+	}
+
+	var block *pem.Block
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(privateKey, comment, []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(privateKey, comment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %v", err)
+	}
+
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create public key: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(privateKeyPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %v", err)
+	}
+
+	// O_EXCL 防止覆盖已有私钥
+	privateFile, err := os.OpenFile(privateKeyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create private key file: %v", err)
+	}
+
+	// 任何一步失败都删除已写入的文件，避免留下不完整的密钥对
+	if err := writeKeyPair(privateFile, block, privateKeyPath+".pub", FormatAuthorizedKey(sshPublicKey, comment)); err != nil {
+		os.Remove(privateKeyPath)
+		return nil, err
+	}
+
+	return sshPublicKey, nil
+}
+
+// writeKeyPair 写入并关闭私钥文件，再写入公钥文件，公钥写入失败时删除公钥文件
+func writeKeyPair(privateFile *os.File, block *pem.Block, publicKeyPath, authorizedKey string) error {
+	if err := pem.Encode(privateFile, block); err != nil {
+		privateFile.Close()
+		return fmt.Errorf("failed to write private key: %v", err)
+	}
+	if err := privateFile.Close(); err != nil {
+		return fmt.Errorf("failed to write private key: %v", err)
+	}
+
+	if err := os.WriteFile(publicKeyPath, []byte(authorizedKey+"\n"), 0644); err != nil {
+		os.Remove(publicKeyPath)
+		return fmt.Errorf("failed to write public key: %v", err)
+	}
+	return nil
+}
+
+// FormatAuthorizedKey 格式化为 authorized_keys 格式的一行（不含换行）
+func FormatAuthorizedKey(publicKey ssh.PublicKey, comment string) string {
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+	if comment != "" {
+		line += " " + comment
+	}
+	return line
+}
+
+// GetDefaultComment 获取默认的密钥注释（user@hostname）
+func GetDefaultComment() string {
+	username := os.Getenv("USER")
+	if username == "" {
+		username = "root"
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return username
+	}
+	return username + "@" + hostname
+}
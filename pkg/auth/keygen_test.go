@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGenerateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "id_ed25519")
+
+	publicKey, err := GenerateKey(path, "me@host", "")
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(readFile(t, path))
+	if err != nil {
+		t.Fatalf("ParsePrivateKey() error = %v", err)
+	}
+	if !bytes.Equal(signer.PublicKey().Marshal(), publicKey.Marshal()) {
+		t.Error("private key does not match returned public key")
+	}
+
+	line := strings.TrimSpace(string(readFile(t, path+".pub")))
+	if want := FormatAuthorizedKey(publicKey, "me@host"); line != want {
+		t.Errorf("public key file = %q, want %q", line, want)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("private key mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestGenerateKeyWithPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+
+	publicKey, err := GenerateKey(path, "", "secret")
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	var missing *ssh.PassphraseMissingError
+	if _, err := ssh.ParsePrivateKey(readFile(t, path)); !errors.As(err, &missing) {
+		t.Fatalf("ParsePrivateKey() without passphrase error = %v, want PassphraseMissingError", err)
+	}
+	signer, err := ssh.ParsePrivateKeyWithPassphrase(readFile(t, path), []byte("secret"))
+	if err != nil {
+		t.Fatalf("ParsePrivateKeyWithPassphrase() error = %v", err)
+	}
+	if !bytes.Equal(signer.PublicKey().Marshal(), publicKey.Marshal()) {
+		t.Error("private key does not match returned public key")
+	}
+}
+
+func TestGenerateKeyKeepsExistingKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, []byte("existing"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GenerateKey(path, "", ""); err == nil {
+		t.Fatal("GenerateKey() over an existing key succeeded")
+	}
+	if got := string(readFile(t, path)); got != "existing" {
+		t.Errorf("existing key overwritten: %q", got)
+	}
+	if _, err := os.Stat(path + ".pub"); !os.IsNotExist(err) {
+		t.Errorf("public key written for an existing key: %v", err)
+	}
+}
+
+func TestGenerateKeyRemovesPartialKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	// 公钥路径是目录时写入失败
+	if err := os.Mkdir(path+".pub", 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GenerateKey(path, "", ""); err == nil {
+		t.Fatal("GenerateKey() with unwritable public key succeeded")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("private key left after failure: %v", err)
+	}
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,136 @@
+// pkg/auth/passphrase.go
+package auth
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxPassphraseAttempts 输入私钥密码的最大尝试次数
+const maxPassphraseAttempts = 3
+
+// ErrIncorrectPassphrase 私钥密码错误
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase")
+
+var (
+	passphraseMu    sync.Mutex
+	passphraseCache = make(map[string][]byte)     // 进程内缓存的私钥密码，按私钥绝对路径索引
+	decryptedKeys   = make(map[string]ssh.Signer) // 已解密的签名器，跳板机与目标主机共享
+)
+
+// encryptedKeySigner 受密码保护的私钥签名器
+// 公钥可直接获取，只有在服务端接受该公钥、真正需要签名时才提示输入密码
+type encryptedKeySigner struct {
+	path      string
+	pemBytes  []byte
+	publicKey ssh.PublicKey
+}
+
+// newEncryptedKeySigner 创建受密码保护私钥的签名器
+// 私钥文件中没有未加密的公钥且不存在 .pub 文件时，只能立即解密
+func newEncryptedKeySigner(privateKeyPath string, pemBytes []byte, publicKey ssh.PublicKey) (ssh.Signer, error) {
+	if publicKey == nil {
+		if pub, err := LoadPublicKey(privateKeyPath); err == nil {
+			publicKey = pub
+		}
+	}
+
+	s := &encryptedKeySigner{
+		path:      privateKeyPath,
+		pemBytes:  pemBytes,
+		publicKey: publicKey,
+	}
+
+	if publicKey == nil {
+		return s.decrypt()
+	}
+	recordKeySource(publicKey, privateKeyPath)
+	return s, nil
+}
+
+// PublicKey 返回私钥对应的公钥
+func (s *encryptedKeySigner) PublicKey() ssh.PublicKey {
+	return s.publicKey
+}
+
+// Sign 解密私钥后签名
+func (s *encryptedKeySigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	signer, err := s.decrypt()
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(rand, data)
+}
+
+// SignWithAlgorithm 解密私钥后使用指定算法签名（RSA 密钥需要 rsa-sha2-* 算法）
+func (s *encryptedKeySigner) SignWithAlgorithm(rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	signer, err := s.decrypt()
+	if err != nil {
+		return nil, err
+	}
+
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok {
+		return algorithmSigner.SignWithAlgorithm(rand, data, algorithm)
+	}
+	if algorithm != "" && algorithm != signer.PublicKey().Type() {
+		return nil, fmt.Errorf("private key %s does not support signature algorithm %s", s.path, algorithm)
+	}
+	return signer.Sign(rand, data)
+}
+
+// decrypt 解密私钥，优先使用缓存的签名器和密码，密码错误时最多重试 maxPassphraseAttempts 次
+func (s *encryptedKeySigner) decrypt() (ssh.Signer, error) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+
+	cacheKey := s.cacheKey()
+	if signer, ok := decryptedKeys[cacheKey]; ok {
+		return signer, nil
+	}
+
+	// 先尝试缓存的密码
+	if passphrase, ok := passphraseCache[cacheKey]; ok {
+		if signer, err := ssh.ParsePrivateKeyWithPassphrase(s.pemBytes, passphrase); err == nil {
+			decryptedKeys[cacheKey] = signer
+			return signer, nil
+		}
+		delete(passphraseCache, cacheKey)
+	}
+
+	for attempt := 1; attempt <= maxPassphraseAttempts; attempt++ {
+		passphrase, err := readSecret(fmt.Sprintf("Enter passphrase for key '%s': ", s.path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase for private key %s: %v", s.path, err)
+		}
+
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(s.pemBytes, []byte(passphrase))
+		if err == nil {
+			passphraseCache[cacheKey] = []byte(passphrase)
+			decryptedKeys[cacheKey] = signer
+			return signer, nil
+		}
+
+		if !errors.Is(err, x509.IncorrectPasswordError) {
+			return nil, fmt.Errorf("failed to decrypt private key %s: %v", s.path, err)
+		}
+		if attempt < maxPassphraseAttempts {
+			fmt.Println("Incorrect passphrase, please try again.")
+		}
+	}
+
+	return nil, fmt.Errorf("private key %s: %w (%d attempts)", s.path, ErrIncorrectPassphrase, maxPassphraseAttempts)
+}
+
+// cacheKey 缓存使用的私钥路径
+func (s *encryptedKeySigner) cacheKey() string {
+	if absPath, err := filepath.Abs(s.path); err == nil {
+		return absPath
+	}
+	return s.path
+}
@@ -0,0 +1,136 @@
+// pkg/auth/passphrase_test.go
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// usePrompts 测试期间依次返回 answers 作为输入，返回提示次数的计数器
+func usePrompts(t *testing.T, answers ...string) *int {
+	t.Helper()
+	count := 0
+	previousSecret, previousLine := promptSecret, promptLine
+	prompt := func(string) (string, error) {
+		count++
+		if len(answers) == 0 {
+			return "", errors.New("unexpected prompt")
+		}
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}
+	promptSecret, promptLine = prompt, prompt
+	t.Cleanup(func() { promptSecret, promptLine = previousSecret, previousLine })
+	return &count
+}
+
+// writeEncryptedKey 生成受密码保护的 OpenSSH 格式 ed25519 私钥
+func writeEncryptedKey(t *testing.T, passphrase string) (string, ssh.PublicKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(private, "", []byte(passphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, publicKey
+}
+
+func TestEncryptedKeyLazyDecrypt(t *testing.T) {
+	path, publicKey := writeEncryptedKey(t, "secret")
+	prompts := usePrompts(t, "wrong", "secret")
+
+	signer, err := LoadPrivateKey(path)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey() error = %v", err)
+	}
+	if *prompts != 0 {
+		t.Fatalf("LoadPrivateKey() prompted %d times, want no prompt before signing", *prompts)
+	}
+	if string(signer.PublicKey().Marshal()) != string(publicKey.Marshal()) {
+		t.Fatal("PublicKey() does not match the key file")
+	}
+
+	signature, err := signer.Sign(rand.Reader, []byte("data"))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if err := publicKey.Verify([]byte("data"), signature); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	if *prompts != 2 {
+		t.Errorf("Sign() prompted %d times, want 2 (one wrong passphrase)", *prompts)
+	}
+
+	// 同一私钥再次加载（如跳板机和目标主机）不再提示
+	again, err := LoadPrivateKey(path)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey() error = %v", err)
+	}
+	if _, err := again.Sign(rand.Reader, []byte("data")); err != nil || *prompts != 2 {
+		t.Errorf("Sign() with the cached key = %v after %d prompts, want no new prompt", err, *prompts)
+	}
+
+	// 已解密的签名器失效时使用缓存的密码
+	passphraseMu.Lock()
+	delete(decryptedKeys, again.(*encryptedKeySigner).cacheKey())
+	passphraseMu.Unlock()
+	if _, err := again.Sign(rand.Reader, []byte("data")); err != nil || *prompts != 2 {
+		t.Errorf("Sign() with the cached passphrase = %v after %d prompts, want no new prompt", err, *prompts)
+	}
+}
+
+func TestEncryptedKeyAttempts(t *testing.T) {
+	path, _ := writeEncryptedKey(t, "secret")
+	prompts := usePrompts(t, "a", "b", "c", "secret")
+
+	signer, err := LoadPrivateKey(path)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey() error = %v", err)
+	}
+	if _, err := signer.Sign(rand.Reader, []byte("data")); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Errorf("Sign() error = %v, want %v", err, ErrIncorrectPassphrase)
+	}
+	if *prompts != maxPassphraseAttempts {
+		t.Errorf("prompted %d times, want %d", *prompts, maxPassphraseAttempts)
+	}
+}
+
+func TestEncryptedKeyWithoutPublicKey(t *testing.T) {
+	path, publicKey := writeEncryptedKey(t, "secret")
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompts := usePrompts(t, "secret")
+
+	// 没有公钥时只能立即解密
+	signer, err := newEncryptedKeySigner(path, pemBytes, nil)
+	if err != nil {
+		t.Fatalf("newEncryptedKeySigner() error = %v", err)
+	}
+	if *prompts != 1 {
+		t.Errorf("prompted %d times, want 1", *prompts)
+	}
+	if string(signer.PublicKey().Marshal()) != string(publicKey.Marshal()) {
+		t.Error("PublicKey() does not match the key file")
+	}
+}
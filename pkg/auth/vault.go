@@ -0,0 +1,203 @@
+// pkg/auth/vault.go
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/vault"
+)
+
+// maxVaultAttempts 输入保险库主密码的最大尝试次数
+const maxVaultAttempts = 3
+
+// ErrNoVault 保险库未初始化，密码不会被保存
+var ErrNoVault = errors.New("vault is not initialized, run 'ssm vault init' to save passwords")
+
+// GetVaultPath 获取保险库文件路径
+func (m *AuthManager) GetVaultPath() string {
+	return vault.GetVaultPath(m.configDir)
+}
+
+// UnlockVault 获取已解锁的保险库
+// 依次尝试进程内缓存、未过期的解锁会话，最后提示输入主密码
+func (m *AuthManager) UnlockVault() (*vault.Vault, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.unlockVault()
+}
+
+// unlockVault 获取已解锁的保险库，调用方需持有 m.mu
+func (m *AuthManager) unlockVault() (*vault.Vault, error) {
+	if m.vault != nil && m.vault.IsUnlocked() {
+		return m.vault, nil
+	}
+
+	path := m.GetVaultPath()
+	if !vault.Exists(path) {
+		return nil, ErrNoVault
+	}
+
+	v, err := vault.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := v.UnlockWithSession(); err == nil {
+		m.vault = v
+		return v, nil
+	}
+
+	for attempt := 1; attempt <= maxVaultAttempts; attempt++ {
+		passphrase, err := readSecret("Vault passphrase: ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault passphrase: %v", err)
+		}
+
+		err = v.Unlock(passphrase)
+		if err == nil {
+			m.vault = v
+			return v, nil
+		}
+		if !errors.Is(err, vault.ErrWrongPassphrase) {
+			return nil, err
+		}
+		if attempt < maxVaultAttempts {
+			fmt.Println("Wrong vault passphrase, please try again.")
+		}
+	}
+
+	return nil, vault.ErrWrongPassphrase
+}
+
+// UseVault 设置进程内已解锁的保险库（如刚创建的保险库），避免再次提示主密码
+func (m *AuthManager) UseVault(v *vault.Vault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vault = v
+}
+
+// getSavedPassword 获取已保存的密码，保险库中的密码在真正需要时才解密
+func (m *AuthManager) getSavedPassword(cfg *config.SSHConfig) (string, error) {
+	if cfg.Password != "" || cfg.PasswordRef == "" {
+		return cfg.Password, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, err := m.unlockVault()
+	if err != nil {
+		return "", fmt.Errorf("failed to unlock vault: %v", err)
+	}
+
+	password, err := v.Get(cfg.PasswordRef)
+	if err != nil {
+		return "", err
+	}
+	cfg.Password = password
+	return password, nil
+}
+
+// SealPassword 将配置中的明文密码存入保险库，配置中只保留引用
+// 写入保险库成功后才清除明文密码，任何错误都不修改配置；保险库未初始化时返回 ErrNoVault
+func (m *AuthManager) SealPassword(cfg *config.SSHConfig) error {
+	if cfg.Password == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, err := m.unlockVault()
+	if err != nil {
+		return err
+	}
+
+	ref := cfg.PasswordRef
+	if ref == "" {
+		ref = vault.NewItemID()
+	}
+
+	// 密码未变化时无需重写保险库
+	if existing, err := v.Get(ref); err != nil || existing != cfg.Password {
+		if err := v.Put(ref, cfg.Password); err != nil {
+			return err
+		}
+	}
+
+	cfg.Password = ""
+	cfg.PasswordRef = ref
+	return nil
+}
+
+// SaveLogin 连接成功后更新最后使用时间并保存配置，密码只保存在保险库中：
+// 存入保险库失败时不保存配置；保险库未初始化时不保存新输入的密码，
+// 旧版本保存的明文密码保持不变，等待 ssm vault migrate 迁移
+func (m *AuthManager) SaveLogin(store config.Store, cfg *config.SSHConfig) error {
+	var dropped error
+	if err := m.SealPassword(cfg); err != nil {
+		if !errors.Is(err, ErrNoVault) {
+			return fmt.Errorf("failed to store password in vault, config not saved: %v", err)
+		}
+		if saved, exists := store.Get(cfg.GetKey()); !exists || saved.Password != cfg.Password {
+			cfg.Password = ""
+			dropped = fmt.Errorf("password not saved: %w", err)
+		}
+	}
+
+	cfg.UpdateLastUsed()
+	if err := store.Put(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %v", err)
+	}
+	return dropped
+}
+
+// ForgetPassword 清除配置中保存的密码，保险库中的对应条目一并删除
+func (m *AuthManager) ForgetPassword(cfg *config.SSHConfig) error {
+	cfg.Password = ""
+	if cfg.PasswordRef == "" {
+		return nil
+	}
+
+	ref := cfg.PasswordRef
+	cfg.PasswordRef = ""
+
+	// 删除条目无需解锁，但进程内已打开的保险库需要同步更新
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v := m.vault
+	if v == nil {
+		path := m.GetVaultPath()
+		if !vault.Exists(path) {
+			return nil
+		}
+
+		var err error
+		if v, err = vault.Open(path); err != nil {
+			return err
+		}
+	}
+	if err := v.Delete(ref); err != nil && !errors.Is(err, vault.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// CopyPassword 为复制出的配置在保险库中保存单独的密码条目，避免两个配置共用一个条目
+func (m *AuthManager) CopyPassword(cfg *config.SSHConfig) error {
+	if cfg.PasswordRef == "" {
+		return nil
+	}
+
+	if _, err := m.getSavedPassword(cfg); err != nil {
+		return err
+	}
+	cfg.PasswordRef = ""
+	return m.SealPassword(cfg)
+}
+
+// LoadSavedPassword 将保险库中的密码解密到配置的 Password 字段，用于导出
+func (m *AuthManager) LoadSavedPassword(cfg *config.SSHConfig) error {
+	_, err := m.getSavedPassword(cfg)
+	return err
+}
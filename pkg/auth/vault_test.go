@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/vault"
+)
+
+// useTestVault 在临时配置目录中创建主密码为 master 的保险库（处于锁定状态）
+func useTestVault(t *testing.T) *AuthManager {
+	t.Helper()
+	m := NewAuthManager(t.TempDir())
+	if _, err := vault.Create(m.GetVaultPath(), "master"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return m
+}
+
+func TestSealPassword(t *testing.T) {
+	m := useTestVault(t)
+	usePrompts(t, "master")
+
+	cfg := &config.SSHConfig{Host: "example.com", Username: "root", Port: "22", Password: "secret"}
+	if err := m.SealPassword(cfg); err != nil {
+		t.Fatalf("SealPassword() error = %v", err)
+	}
+	if cfg.Password != "" || cfg.PasswordRef == "" {
+		t.Fatalf("SealPassword() left Password = %q, PasswordRef = %q", cfg.Password, cfg.PasswordRef)
+	}
+	if got, err := m.vault.Get(cfg.PasswordRef); err != nil || got != "secret" {
+		t.Errorf("vault item = %q, %v, want %q", got, err, "secret")
+	}
+
+	ref := cfg.PasswordRef
+	if err := m.ForgetPassword(cfg); err != nil {
+		t.Fatalf("ForgetPassword() error = %v", err)
+	}
+	if _, err := m.vault.Get(ref); !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("vault item after ForgetPassword() error = %v, want %v", err, vault.ErrNotFound)
+	}
+}
+
+func TestSealPasswordUnlockFailure(t *testing.T) {
+	m := useTestVault(t)
+	usePrompts(t, "wrong", "wrong", "wrong")
+
+	cfg := &config.SSHConfig{Host: "example.com", Username: "root", Port: "22", Password: "secret", PasswordRef: "pw-old"}
+	before := *cfg
+	if err := m.SealPassword(cfg); !errors.Is(err, vault.ErrWrongPassphrase) {
+		t.Fatalf("SealPassword() error = %v, want %v", err, vault.ErrWrongPassphrase)
+	}
+	if !reflect.DeepEqual(*cfg, before) {
+		t.Errorf("SealPassword() changed config on error: %+v, want %+v", *cfg, before)
+	}
+}
+
+func TestSealPasswordWithoutVault(t *testing.T) {
+	m := NewAuthManager(t.TempDir())
+	usePrompts(t)
+
+	cfg := &config.SSHConfig{Host: "example.com", Username: "root", Port: "22", Password: "secret"}
+	if err := m.SealPassword(cfg); !errors.Is(err, ErrNoVault) {
+		t.Fatalf("SealPassword() error = %v, want %v", err, ErrNoVault)
+	}
+	if cfg.Password != "secret" || cfg.PasswordRef != "" {
+		t.Errorf("SealPassword() without vault changed config: %+v", *cfg)
+	}
+	if _, err := os.Stat(m.GetVaultPath()); !os.IsNotExist(err) {
+		t.Errorf("SealPassword() created a vault: %v", err)
+	}
+}
+
+func TestSaveLogin(t *testing.T) {
+	t.Run("sealed", func(t *testing.T) {
+		m := useTestVault(t)
+		usePrompts(t, "master")
+		store := config.NewMemoryStore()
+
+		cfg := &config.SSHConfig{Host: "example.com", Username: "root", Port: "22", Password: "secret"}
+		if err := m.SaveLogin(store, cfg); err != nil {
+			t.Fatalf("SaveLogin() error = %v", err)
+		}
+		saved, exists := store.Get(cfg.GetKey())
+		if !exists || saved.Password != "" || saved.PasswordRef == "" || saved.LastUsed == "" {
+			t.Errorf("saved config = %+v, want sealed password and last used time", saved)
+		}
+	})
+
+	t.Run("unlock failure", func(t *testing.T) {
+		m := useTestVault(t)
+		usePrompts(t, "wrong", "wrong", "wrong")
+		store := config.NewMemoryStore()
+
+		cfg := &config.SSHConfig{Host: "example.com", Username: "root", Port: "22", Password: "secret"}
+		if err := m.SaveLogin(store, cfg); err == nil {
+			t.Fatalf("SaveLogin() error = %v, want an error", err)
+		}
+		if saved, exists := store.Get(cfg.GetKey()); exists {
+			t.Errorf("SaveLogin() saved %+v after sealing failed", saved)
+		}
+	})
+
+	t.Run("no vault", func(t *testing.T) {
+		m := NewAuthManager(t.TempDir())
+		usePrompts(t)
+		legacy := config.SSHConfig{Host: "legacy.example.com", Username: "root", Port: "22", Password: "old"}
+		store := config.NewMemoryStore(legacy)
+
+		cfg := &config.SSHConfig{Host: "example.com", Username: "root", Port: "22", Password: "secret"}
+		if err := m.SaveLogin(store, cfg); !errors.Is(err, ErrNoVault) {
+			t.Fatalf("SaveLogin() error = %v, want %v", err, ErrNoVault)
+		}
+		if saved, exists := store.Get(cfg.GetKey()); !exists || saved.Password != "" {
+			t.Errorf("saved config = %+v, want config without password", saved)
+		}
+
+		// 旧版本保存的明文密码保持不变
+		old := legacy
+		if err := m.SaveLogin(store, &old); err != nil {
+			t.Fatalf("SaveLogin() legacy error = %v", err)
+		}
+		if saved, _ := store.Get(legacy.GetKey()); saved.Password != "old" {
+			t.Errorf("legacy password = %q, want %q", saved.Password, "old")
+		}
+	})
+}
+
+func TestParallelAuthPrompts(t *testing.T) {
+	m := useTestVault(t)
+
+	// 记录同时进行的提示，并行连接时终端提示必须逐个进行
+	var mu sync.Mutex
+	active, overlaps, vaultPrompts := 0, 0, 0
+	prompt := func(prompt string) (string, error) {
+		mu.Lock()
+		active++
+		if active > 1 {
+			overlaps++
+		}
+		if strings.HasPrefix(prompt, "Vault passphrase") {
+			vaultPrompts++
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		if strings.HasPrefix(prompt, "Vault passphrase") {
+			return "master", nil
+		}
+		return "123456", nil
+	}
+	previousSecret, previousLine := promptSecret, promptLine
+	promptSecret, promptLine = prompt, prompt
+	t.Cleanup(func() { promptSecret, promptLine = previousSecret, previousLine })
+
+	var wg sync.WaitGroup
+	configs := make([]*config.SSHConfig, 8)
+	for i := range configs {
+		cfg := &config.SSHConfig{Host: fmt.Sprintf("10.0.0.%d", i+1), Username: "root", Port: "22", Password: "secret"}
+		configs[i] = cfg
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			challenge := m.keyboardInteractiveChallenge(cfg)
+			if _, err := challenge("", "", []string{"Verification code: "}, []bool{false}); err != nil {
+				t.Errorf("challenge() error = %v", err)
+			}
+			if err := m.SealPassword(cfg); err != nil {
+				t.Errorf("SealPassword() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if overlaps != 0 {
+		t.Errorf("%d prompts overlapped", overlaps)
+	}
+	if vaultPrompts != 1 {
+		t.Errorf("vault passphrase prompted %d times, want 1", vaultPrompts)
+	}
+	for _, cfg := range configs {
+		if cfg.Password != "" || cfg.PasswordRef == "" {
+			t.Errorf("%s: Password = %q, PasswordRef = %q after SealPassword()", cfg.Host, cfg.Password, cfg.PasswordRef)
+		}
+	}
+}
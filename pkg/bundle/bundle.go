@@ -0,0 +1,274 @@
+// pkg/bundle/bundle.go
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/jump"
+	"github.com/wuxs/ssm/pkg/vault"
+)
+
+const (
+	// format 文件格式标识
+	format = "ssm-bundle"
+	// currentVersion 文件格式版本
+	currentVersion = 1
+	// sealAAD 加密清单时绑定的附加数据
+	sealAAD = "ssm-bundle"
+)
+
+// ErrWrongPassphrase 口令错误或文件被修改
+var ErrWrongPassphrase = errors.New("wrong bundle passphrase")
+
+// Bundle 导出的主机清单
+type Bundle struct {
+	CreatedAt string             `json:"created_at"`
+	Secrets   bool               `json:"secrets"`        // 是否包含密码和密钥文件
+	Hosts     []config.SSHConfig `json:"hosts"`          // 主机和模式
+	Keys      map[string]string  `json:"keys,omitempty"` // 私钥和证书文件的内容，键为配置中的路径
+}
+
+// File 清单文件，清单以明文或加密形式保存
+type File struct {
+	Format    string            `json:"format"`
+	Version   int               `json:"version"`
+	Encrypted *vault.SealedData `json:"encrypted,omitempty"`
+	Bundle    *Bundle           `json:"bundle,omitempty"`
+}
+
+// Select 选出带有所有指定标签的主机，以及它们经过的跳板机和匹配的模式，保持原顺序；
+// 未指定标签时选出全部配置
+func Select(configs []config.SSHConfig, tags []string) []config.SSHConfig {
+	if len(tags) == 0 {
+		return configs
+	}
+
+	hosts, patterns := config.SplitPatterns(configs)
+	byKey := make(map[string]config.SSHConfig, len(hosts))
+	for _, host := range hosts {
+		byKey[host.GetKey()] = host
+	}
+	store := config.NewMemoryStore(configs...)
+
+	selected := make(map[string]bool)
+	var queue []config.SSHConfig
+	for _, host := range hosts {
+		if host.MatchTags(tags) {
+			selected[host.GetKey()] = true
+			queue = append(queue, host)
+		}
+	}
+
+	// 跳板机按连接时的方式查找，跳板机可能还有自己的跳板机
+	for i := 0; i < len(queue); i++ {
+		for _, hop := range jump.ParseChain(queue[i].ProxyJump) {
+			key := jump.LoadHopConfig(store, hop).GetKey()
+			if host, ok := byKey[key]; ok && !selected[key] {
+				selected[key] = true
+				queue = append(queue, host)
+			}
+		}
+	}
+
+	for _, pattern := range patterns {
+		for _, host := range queue {
+			if config.MatchPattern(pattern.Host, host.Host) {
+				selected[pattern.Host] = true
+				break
+			}
+		}
+	}
+
+	var result []config.SSHConfig
+	for _, cfg := range configs {
+		if selected[cfg.GetKey()] {
+			result = append(result, cfg)
+		}
+	}
+	return result
+}
+
+// New 创建清单。保险库引用总是去掉，主目录下的路径改写为 ~/ 开头；
+// secrets 为 false 时去掉密码，为 true 时保存明文密码（需先从保险库取出）和私钥、证书文件的内容
+func New(configs []config.SSHConfig, secrets bool) (*Bundle, error) {
+	b := &Bundle{
+		CreatedAt: time.Now().Format(time.RFC3339),
+		Secrets:   secrets,
+		Hosts:     make([]config.SSHConfig, 0, len(configs)),
+	}
+
+	for _, cfg := range configs {
+		cfg.PasswordRef = ""
+		if !secrets {
+			cfg.Password = ""
+		}
+
+		for _, path := range []*string{&cfg.PrivateKey, &cfg.Certificate} {
+			if *path == "" {
+				continue
+			}
+			if secrets {
+				content, err := os.ReadFile(*path)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read key file for %s: %v", cfg.GetKey(), err)
+				}
+				if b.Keys == nil {
+					b.Keys = make(map[string]string)
+				}
+				b.Keys[PortablePath(*path)] = string(content)
+			}
+			*path = PortablePath(*path)
+		}
+		b.Hosts = append(b.Hosts, cfg)
+	}
+	return b, nil
+}
+
+// Marshal 生成清单文件内容，passphrase 不为空时加密整个清单
+func Marshal(b *Bundle, passphrase string) ([]byte, error) {
+	file := File{Format: format, Version: currentVersion}
+	if passphrase == "" {
+		file.Bundle = b
+	} else {
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		if file.Encrypted, err = vault.SealWithPassphrase(passphrase, data, sealAAD); err != nil {
+			return nil, err
+		}
+	}
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// Parse 解析清单文件，加密的清单需再调用 Open 解密
+func Parse(data []byte) (*File, error) {
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil || file.Format != format {
+		return nil, fmt.Errorf("not an ssm bundle")
+	}
+	if file.Version > currentVersion {
+		return nil, fmt.Errorf("bundle version %d is newer than supported version %d, please upgrade ssm", file.Version, currentVersion)
+	}
+	if file.Bundle == nil && file.Encrypted == nil {
+		return nil, fmt.Errorf("bundle is empty")
+	}
+	return &file, nil
+}
+
+// IsEncrypted 清单是否加密
+func (f *File) IsEncrypted() bool {
+	return f.Bundle == nil
+}
+
+// Open 获取清单，加密的清单使用 passphrase 解密
+func (f *File) Open(passphrase string) (*Bundle, error) {
+	if f.Bundle != nil {
+		return f.Bundle, nil
+	}
+
+	data, err := vault.OpenWithPassphrase(passphrase, f.Encrypted, sealAAD)
+	if errors.Is(err, vault.ErrWrongPassphrase) {
+		return nil, ErrWrongPassphrase
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %v", err)
+	}
+	return &b, nil
+}
+
+// LocalKeys 为清单中的密钥文件选择本地路径：本机相同路径已有相同内容时直接使用，
+// 否则放到 dir 下，文件名已被不同内容占用时加上 -2、-3 等后缀。返回清单路径 -> 本地路径
+func (b *Bundle) LocalKeys(dir string) map[string]string {
+	paths := make([]string, 0, len(b.Keys))
+	for path := range b.Keys {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	targets := make(map[string]string, len(paths))
+	claimed := make(map[string]string) // 本地路径 -> 内容
+	for _, path := range paths {
+		content := b.Keys[path]
+		if local := LocalPath(path); hasContent(local, content) {
+			targets[path] = local
+			continue
+		}
+
+		base := filepath.Base(path)
+		for n := 1; ; n++ {
+			target := filepath.Join(dir, base)
+			if n > 1 {
+				target = filepath.Join(dir, fmt.Sprintf("%s-%d", base, n))
+			}
+			if existing, ok := claimed[target]; ok && existing != content {
+				continue
+			}
+			if _, err := os.Stat(target); err == nil && !hasContent(target, content) {
+				continue
+			}
+			claimed[target] = content
+			targets[path] = target
+			break
+		}
+	}
+	return targets
+}
+
+// InstallKey 写入密钥文件（权限 0600），文件已存在且内容相同时不做修改
+func InstallKey(path, content string) error {
+	if hasContent(path, content) {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("key file %s already exists with different content", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %v", err)
+	}
+	return os.WriteFile(path, []byte(content), 0600)
+}
+
+// PortablePath 主目录下的路径改写为 ~/ 开头，以便在其他用户的机器上使用
+func PortablePath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~/" + filepath.ToSlash(rest)
+	}
+	return path
+}
+
+// LocalPath 将 ~/ 开头的路径展开为本机主目录下的路径
+func LocalPath(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, filepath.FromSlash(rest))
+}
+
+// hasContent 文件是否存在且内容与 content 相同
+func hasContent(path, content string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && string(data) == content
+}
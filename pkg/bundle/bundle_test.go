@@ -0,0 +1,140 @@
+// pkg/bundle/bundle_test.go
+package bundle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+func TestSelect(t *testing.T) {
+	configs := []config.SSHConfig{
+		{Alias: "web1", Host: "10.0.0.1", Username: "root", Port: "22", Tags: []string{"prod"}, ProxyJump: "bastion, jump.internal"},
+		{Alias: "bastion", Host: "10.0.0.254", Username: "admin", Port: "22", ProxyJump: "admin@gw.example.com"},
+		{Host: "gw.example.com", Username: "admin", Port: "22"},
+		{Host: "jump.internal", Username: "deploy", Port: "22"},
+		{Host: "10.0.1.1", Username: "root", Port: "22", Tags: []string{"dev"}},
+		{Host: "10.0.0.*", Username: "root"},
+		{Host: "*.internal", Username: "deploy"},
+	}
+
+	var got []string
+	for _, cfg := range Select(configs, []string{"prod"}) {
+		got = append(got, cfg.GetKey())
+	}
+	// 跳板机按连接时的方式查找：去掉空白，未指定用户名时使用匹配模式的用户名
+	want := []string{"root@10.0.0.1:22", "admin@10.0.0.254:22", "admin@gw.example.com:22", "deploy@jump.internal:22", "10.0.0.*", "*.internal"}
+	if len(got) != len(want) {
+		t.Fatalf("Select() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Select() = %v, want %v", got, want)
+		}
+	}
+
+	if all := Select(configs, nil); len(all) != len(configs) {
+		t.Errorf("Select() without tags returned %d configs, want %d", len(all), len(configs))
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	keyPath := filepath.Join(home, ".ssh", "prod_key")
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, []byte("KEY"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	configs := []config.SSHConfig{{Host: "10.0.0.1", Username: "root", Port: "22", PrivateKey: keyPath, Password: "pw", PasswordRef: "pw-1"}}
+
+	stripped, err := New(configs, false)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	host := stripped.Hosts[0]
+	if host.Password != "" || host.PasswordRef != "" || host.PrivateKey != "~/.ssh/prod_key" || stripped.Keys != nil {
+		t.Errorf("New() without secrets = %+v, keys %v", host, stripped.Keys)
+	}
+
+	withSecrets, err := New(configs, true)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := Marshal(withSecrets, "passphrase")
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	file, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !file.IsEncrypted() {
+		t.Fatal("IsEncrypted() = false for a bundle written with a passphrase")
+	}
+	if _, err := file.Open("wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Open() with wrong passphrase error = %v, want %v", err, ErrWrongPassphrase)
+	}
+	b, err := file.Open("passphrase")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if b.Hosts[0].Password != "pw" || b.Hosts[0].PasswordRef != "" || b.Keys["~/.ssh/prod_key"] != "KEY" {
+		t.Errorf("Open() = %+v, keys %v", b.Hosts[0], b.Keys)
+	}
+
+	if _, err := Parse([]byte(`{"items": {}}`)); err == nil {
+		t.Error("Parse() of a config file expected an error")
+	}
+}
+
+func TestLocalKeys(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".ssm", "keys")
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "same"), []byte("SAME"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Bundle{Keys: map[string]string{
+		"~/.ssh/same":       "SAME",
+		"~/.ssh/id_rsa":     "A",
+		"/other/id_rsa":     "B",
+		"/another/id_rsa":   "A",
+		"~/work/deploy.pem": "C",
+	}}
+	targets := b.LocalKeys(dir)
+
+	want := map[string]string{
+		"~/.ssh/same":       filepath.Join(home, ".ssh", "same"),
+		"/another/id_rsa":   filepath.Join(dir, "id_rsa"),
+		"/other/id_rsa":     filepath.Join(dir, "id_rsa-2"),
+		"~/.ssh/id_rsa":     filepath.Join(dir, "id_rsa"),
+		"~/work/deploy.pem": filepath.Join(dir, "deploy.pem"),
+	}
+	for path, target := range want {
+		if targets[path] != target {
+			t.Errorf("LocalKeys()[%s] = %s, want %s", path, targets[path], target)
+		}
+	}
+
+	if err := InstallKey(targets["/other/id_rsa"], "B"); err != nil {
+		t.Fatalf("InstallKey() error = %v", err)
+	}
+	if info, err := os.Stat(targets["/other/id_rsa"]); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("installed key = %v, %v, want mode 0600", info, err)
+	}
+	if err := InstallKey(targets["/other/id_rsa"], "other"); err == nil {
+		t.Error("InstallKey() over a different key expected an error")
+	}
+}
@@ -2,32 +2,57 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
+	"regexp"
+	"strconv"
 	"time"
 )
 
+// aliasPattern 别名格式，不能包含 @、: 等字符，避免与 user@host:port 混淆
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
 // SSHConfig 表示SSH连接配置项
 type SSHConfig struct {
-	Host       string `json:"host"`
-	Username   string `json:"username"`
-	Port       string `json:"port"`
-	PrivateKey string `json:"private_key,omitempty"`
-	Password   string `json:"password,omitempty"`
-	ProxyJump  string `json:"-"`
-	LastUsed   string `json:"last_used"`
+	Alias          string   `json:"alias,omitempty"` // 唯一的别名，可代替 user@host:port 使用
+	Group          string   `json:"group,omitempty"` // 所属分组，--list 按分组显示
+	Tags           []string `json:"tags,omitempty"`  // 标签（已排序），用于筛选主机
+	Notes          string   `json:"notes,omitempty"` // 备注，不带参数运行 ssm 选择主机时可以搜索
+	Host           string   `json:"host"`
+	Username       string   `json:"username"`
+	Port           string   `json:"port"`
+	PrivateKey     string   `json:"private_key,omitempty"`
+	Password       string   `json:"password,omitempty"`
+	PasswordRef    string   `json:"password_ref,omitempty"`    // 保险库中的密码条目ID
+	Certificate    string   `json:"certificate,omitempty"`     // OpenSSH 用户证书，默认使用 <私钥>-cert.pub
+	IdentitiesOnly bool     `json:"identities_only,omitempty"` // 只使用 PrivateKey 指定的身份认证
+	ForwardAgent   bool     `json:"forward_agent,omitempty"`   // 交互式会话开启 agent 转发
+
+	// 算法列表，OpenSSH 语法：逗号分隔，可用 +/-/^ 前缀在默认列表上追加、移除、前置
+	Ciphers           string `json:"ciphers,omitempty"`
+	MACs              string `json:"macs,omitempty"`
+	KeyExchanges      string `json:"kex_algorithms,omitempty"`
+	HostKeyAlgorithms string `json:"host_key_algorithms,omitempty"`
+
+	ProxyJump string   `json:"proxy_jump,omitempty"`
+	Overrides []string `json:"overrides,omitempty"` // 在主机上显式设为空或 false 的字段（JSON 名称），不从模式继承
+	LastUsed  string   `json:"last_used"`
+
+	inherited map[string]any // 从模式继承的字段（JSON 名称）及其值，保存时去掉，见 ApplyPatterns
 }
 
 // ConfigStore 表示SSH连接配置存储
 type ConfigStore struct {
-	Items map[string]SSHConfig `json:"items"`
+	Version int                  `json:"version"` // 配置文件格式版本，见 CurrentVersion
+	Items   map[string]SSHConfig `json:"items"`
 }
 
-// GetKey 获取配置的唯一键
+// GetKey 获取配置的唯一键，模式配置的键为模式本身
 func (c *SSHConfig) GetKey() string {
+	if c.IsPattern() {
+		return c.Host
+	}
 	return fmt.Sprintf("%s@%s:%s", c.Username, c.Host, c.Port)
 }
 
@@ -36,20 +61,33 @@ func (c *SSHConfig) UpdateLastUsed() {
 	c.LastUsed = time.Now().Format(time.RFC3339)
 }
 
-// GetAuthDescription 获取认证方式描述
-func (c *SSHConfig) GetAuthDescription() string {
-	authDesc := ""
+// HasSavedPassword 是否保存了密码（明文或保险库引用）
+func (c *SSHConfig) HasSavedPassword() bool {
+	return c.Password != "" || c.PasswordRef != ""
+}
+
+// GetAuthMethod 获取认证方式：key、password、password(vault)、password + key 或 none
+func (c *SSHConfig) GetAuthMethod() string {
+	password := "password"
+	if c.Password == "" && c.PasswordRef != "" {
+		password = "password(vault)"
+	}
+
 	switch {
-	case c.PrivateKey != "" && c.Password != "":
-		authDesc = "password + key"
+	case c.PrivateKey != "" && c.HasSavedPassword():
+		return password + " + key"
 	case c.PrivateKey != "":
-		authDesc = "key"
-	case c.Password != "":
-		authDesc = "password"
+		return "key"
+	case c.HasSavedPassword():
+		return password
 	default:
-		authDesc = "none"
+		return "none"
 	}
+}
 
+// GetAuthDescription 获取认证方式描述，包括跳板机
+func (c *SSHConfig) GetAuthDescription() string {
+	authDesc := c.GetAuthMethod()
 	if c.ProxyJump != "" {
 		authDesc += fmt.Sprintf(" + jump(%s)", c.ProxyJump)
 	}
@@ -57,113 +95,47 @@ func (c *SSHConfig) GetAuthDescription() string {
 	return authDesc
 }
 
-// Load 加载配置
-func Load() (*ConfigStore, error) {
-	configFile := getConfigFilePath()
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		return &ConfigStore{Items: make(map[string]SSHConfig)}, nil
-	}
-
-	data, err := os.ReadFile(configFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %v", err)
+// FindAlias 查找使用该别名的配置键
+func (s *ConfigStore) FindAlias(alias string) (string, bool) {
+	for key, config := range s.Items {
+		if config.Alias == alias {
+			return key, true
+		}
 	}
-
-	var store ConfigStore
-	if err := json.Unmarshal(data, &store); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %v", err)
-	}
-
-	if store.Items == nil {
-		store.Items = make(map[string]SSHConfig)
-	}
-
-	return &store, nil
+	return "", false
 }
 
-// Save 保存配置
-func Save(store *ConfigStore) error {
-	configFile := getConfigFilePath()
-	dir := filepath.Dir(configFile)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("failed to create config directory: %v", err)
+// ValidateAlias 检查别名格式
+func ValidateAlias(alias string) error {
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("invalid alias %q: use letters, digits, '.', '_' and '-' only", alias)
 	}
-
-	data, err := json.MarshalIndent(store, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %v", err)
-	}
-
-	return os.WriteFile(configFile, data, 0600)
+	return nil
 }
 
-// Get 获取配置
-func Get(key string) (*SSHConfig, bool) {
-	store, err := Load()
-	if err != nil {
-		return nil, false
+// ValidatePort 检查端口，必须是 1-65535 的数字
+func ValidatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q: use a number between 1 and 65535", port)
 	}
-
-	config, exists := store.Items[key]
-	if !exists {
-		return nil, false
-	}
-	return &config, true
-}
-
-// SaveConfig 保存单个配置
-func SaveConfig(config *SSHConfig) error {
-	store, err := Load()
-	if err != nil {
-		return err
-	}
-
-	key := config.GetKey()
-	store.Items[key] = *config
-
-	return Save(store)
-}
-
-// Delete 删除配置
-func Delete(key string) error {
-	store, err := Load()
-	if err != nil {
-		return err
-	}
-
-	if _, exists := store.Items[key]; !exists {
-		return fmt.Errorf("connection config not found: %s", key)
-	}
-
-	delete(store.Items, key)
-	return Save(store)
+	return nil
 }
 
-// List 列出所有配置
-func List() ([]SSHConfig, error) {
-	store, err := Load()
-	if err != nil {
-		return nil, err
-	}
-
-	configs := make([]SSHConfig, 0, len(store.Items))
-	for _, config := range store.Items {
-		configs = append(configs, config)
+// GetConfigDir 获取配置目录，可用环境变量 SSM_HOME 指定，默认为 ~/.ssm
+func GetConfigDir() string {
+	if dir := os.Getenv("SSM_HOME"); dir != "" {
+		return dir
 	}
 
-	// 按最后使用时间排序
-	sort.Slice(configs, func(i, j int) bool {
-		return configs[i].LastUsed > configs[j].LastUsed
-	})
-
-	return configs, nil
-}
-
-// getConfigFilePath 获取配置文件路径
-func getConfigFilePath() string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		homeDir = os.Getenv("HOME")
 	}
-	return filepath.Join(homeDir, ".ssm", "ssh_config.json")
+	return filepath.Join(homeDir, ".ssm")
+}
+
+// GetConfigFilePath 获取默认的配置文件路径
+func GetConfigFilePath() string {
+	return filepath.Join(GetConfigDir(), "ssh_config.json")
 }
@@ -0,0 +1,159 @@
+// pkg/config/config_test.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// newTestFileStore 使用临时目录中的配置文件
+func newTestFileStore(t *testing.T) *FileStore {
+	t.Helper()
+	return NewFileStore(filepath.Join(t.TempDir(), "ssh_config.json"))
+}
+
+func TestConcurrentPut(t *testing.T) {
+	store := newTestFileStore(t)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cfg := &SSHConfig{Host: fmt.Sprintf("host%d", i), Username: "root", Port: "22"}
+			if err := store.Put(cfg); err != nil {
+				t.Errorf("Put() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	configs, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(configs) != writers {
+		t.Errorf("List() has %d items, want %d", len(configs), writers)
+	}
+}
+
+func TestLoadRecoversFromBackup(t *testing.T) {
+	fileStore := newTestFileStore(t)
+
+	first := &SSHConfig{Host: "first", Username: "root", Port: "22"}
+	second := &SSHConfig{Host: "second", Username: "root", Port: "22"}
+	if err := fileStore.Put(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileStore.Put(second); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟写入中途崩溃留下的半个文件
+	configFile := fileStore.Path()
+	if err := os.WriteFile(configFile, []byte(`{"items": {"root@fir`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := fileStore.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, exists := store.Items[first.GetKey()]; !exists {
+		t.Errorf("Load() did not restore %s from the backup", first.GetKey())
+	}
+
+	if _, _, err := readStore(configFile); err != nil {
+		t.Errorf("config file was not restored: %v", err)
+	}
+	if _, err := os.Stat(configFile + ".corrupt"); err != nil {
+		t.Errorf("damaged config file was not kept: %v", err)
+	}
+}
+
+func TestLoadWithoutBackup(t *testing.T) {
+	fileStore := newTestFileStore(t)
+	if err := os.WriteFile(fileStore.Path(), []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fileStore.Load(); err == nil {
+		t.Error("Load() expected an error without a backup")
+	}
+}
+
+func TestLoadMigratesOldVersion(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// 版本 1：没有 version 字段，未指定 -i 时记录了 ~/.ssh/id_rsa，升级结果与该文件是否存在无关
+	defaultKey := filepath.Join(home, ".ssh", "id_rsa")
+	if err := os.MkdirAll(filepath.Dir(defaultKey), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(defaultKey, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldConfig := fmt.Sprintf(`{"items": {
+		"root@a:22": {"host": "a", "username": "root", "port": "22", "private_key": %q},
+		"root@b:22": {"host": "b", "username": "root", "port": "22", "private_key": "/keys/b"},
+		"root@c:22": {"host": "c", "username": "root", "port": "22", "private_key": %q, "identities_only": true}
+	}}`, defaultKey, defaultKey)
+
+	fileStore := newTestFileStore(t)
+	configFile := fileStore.Path()
+	if err := os.WriteFile(configFile, []byte(oldConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := fileStore.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if store.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", store.Version, CurrentVersion)
+	}
+	if key := store.Items["root@a:22"].PrivateKey; key != "" {
+		t.Errorf("implicit default key was not dropped: %q", key)
+	}
+	if key := store.Items["root@b:22"].PrivateKey; key != "/keys/b" {
+		t.Errorf("explicit key = %q, want /keys/b", key)
+	}
+	if key := store.Items["root@c:22"].PrivateKey; key != defaultKey {
+		t.Errorf("identities-only key = %q, want %q", key, defaultKey)
+	}
+
+	// 升级后的文件已写回，原文件已备份
+	if _, version, err := readStore(configFile); err != nil || version != CurrentVersion {
+		t.Errorf("config file version = %d, %v, want %d", version, err, CurrentVersion)
+	}
+	backup, err := os.ReadFile(configFile + ".v1.bak")
+	if err != nil || string(backup) != oldConfig {
+		t.Errorf("version 1 backup missing or changed: %v", err)
+	}
+}
+
+func TestLoadRefusesNewerVersion(t *testing.T) {
+	fileStore := newTestFileStore(t)
+	configFile := fileStore.Path()
+
+	newer := []byte(fmt.Sprintf(`{"version": %d, "items": {}}`, CurrentVersion+1))
+	if err := os.WriteFile(configFile, newer, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fileStore.Load(); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Load() error = %v, want ErrNewerVersion", err)
+	}
+	if err := fileStore.Put(&SSHConfig{Host: "a", Username: "root", Port: "22"}); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Put() error = %v, want ErrNewerVersion", err)
+	}
+	if data, _ := os.ReadFile(configFile); string(data) != string(newer) {
+		t.Errorf("newer config file was modified")
+	}
+}
@@ -0,0 +1,216 @@
+// pkg/config/fields.go
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FieldNames SSHConfig 所有字段的 JSON 名称，按定义顺序
+func FieldNames() []string {
+	t := reflect.TypeOf(SSHConfig{})
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fieldValue 按 JSON 名称获取字段，可以修改
+func (c *SSHConfig) fieldValue(name string) (reflect.Value, bool) {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Field 按 JSON 名称获取字段的值（string、bool 或 []string）
+func (c *SSHConfig) Field(name string) (any, bool) {
+	v, ok := c.fieldValue(name)
+	if !ok {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+// FieldString 按 JSON 名称获取字段的显示值：布尔值为 yes 或空，列表以逗号分隔
+func (c *SSHConfig) FieldString(name string) string {
+	v, ok := c.fieldValue(name)
+	if !ok {
+		return ""
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return "yes"
+		}
+		return ""
+	case reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	default:
+		return v.String()
+	}
+}
+
+// SetField 按 JSON 名称设置字段，检查值的格式：布尔值可用 yes/no、true/false、on/off、1/0，
+// 标签以逗号分隔，最后使用时间为 RFC3339 格式。不检查文件是否存在。
+// 可继承的字段设为空或 no 时记为覆盖，不再从模式继承（见 TrackOverride）
+func (c *SSHConfig) SetField(name, value string) error {
+	v, ok := c.fieldValue(name)
+	if !ok {
+		return fmt.Errorf("unknown field %q", name)
+	}
+
+	switch name {
+	case "host":
+		if err := validateHost(value); err != nil {
+			return err
+		}
+	case "username":
+		if value == "" || strings.ContainsAny(value, "@: \t") {
+			return fmt.Errorf("invalid username %q", value)
+		}
+	case "port":
+		if err := ValidatePort(value); err != nil {
+			return err
+		}
+	case "alias":
+		if err := ValidateAlias(value); err != nil {
+			return err
+		}
+	case "group":
+		if err := ValidateGroup(value); err != nil {
+			return err
+		}
+	case "tags":
+		var tags []string
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag == "" {
+				continue
+			}
+			if err := ValidateTag(tag); err != nil {
+				return err
+			}
+			tags = append(tags, tag)
+		}
+		c.Tags = nil
+		c.AddTags(tags...)
+		return nil
+	case "overrides":
+		var overrides []string
+		for _, override := range strings.Split(value, ",") {
+			if override = strings.TrimSpace(override); override == "" {
+				continue
+			}
+			if !isInheritable(override) {
+				return fmt.Errorf("invalid override %q: use one of %s", override, strings.Join(inheritableFields, ", "))
+			}
+			if !slices.Contains(overrides, override) {
+				overrides = append(overrides, override)
+			}
+		}
+		sort.Strings(overrides)
+		c.Overrides = overrides
+		return nil
+	case "proxy_jump":
+		// 与 OpenSSH 一致，none 表示不使用跳板机（也不继承模式的跳板机）
+		if value == "none" {
+			value = ""
+			break
+		}
+		if err := validateProxyJump(value); err != nil {
+			return err
+		}
+	case "last_used":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("invalid last_used %q: use RFC3339, e.g. 2026-03-10T11:00:00Z", value)
+		}
+	}
+
+	if v.Kind() == reflect.Bool {
+		b, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: use yes or no", name, value)
+		}
+		v.SetBool(b)
+	} else {
+		v.SetString(value)
+	}
+	c.TrackOverride(name)
+	return nil
+}
+
+// UnsetField 按 JSON 名称清空字段，清空后重新从模式继承。主机、用户名和端口组成配置键，不能清空
+func (c *SSHConfig) UnsetField(name string) error {
+	v, ok := c.fieldValue(name)
+	if !ok {
+		return fmt.Errorf("unknown field %q", name)
+	}
+	if name == "host" || name == "username" || name == "port" {
+		return fmt.Errorf("%s is part of the config key and cannot be unset", name)
+	}
+	v.Set(reflect.Zero(v.Type()))
+	c.removeOverride(name)
+	return nil
+}
+
+// validateHost 检查主机名或模式
+func validateHost(host string) error {
+	if IsPattern(host) {
+		return ValidatePattern(host)
+	}
+	if host == "" || strings.ContainsAny(host, "@:/ \t") {
+		return fmt.Errorf("invalid host %q", host)
+	}
+	return nil
+}
+
+// validateProxyJump 检查跳板机列表：逗号分隔的 [user@]hostname[:port] 或别名
+func validateProxyJump(proxyJump string) error {
+	for _, hop := range strings.Split(proxyJump, ",") {
+		if strings.TrimSpace(hop) == "" {
+			return fmt.Errorf("invalid proxy_jump %q: empty jump host", proxyJump)
+		}
+		at := strings.LastIndex(hop, "@")
+		if _, port, found := strings.Cut(hop[at+1:], ":"); found {
+			if err := ValidatePort(port); err != nil {
+				return fmt.Errorf("invalid proxy_jump %q: %v", proxyJump, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseBool 解析布尔值
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "true", "on", "1":
+		return true, nil
+	case "no", "false", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", value)
+}
+
+// jsonName 字段的 JSON 名称，不序列化的字段为空
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
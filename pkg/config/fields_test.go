@@ -0,0 +1,70 @@
+// pkg/config/fields_test.go
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetField(t *testing.T) {
+	cfg := SSHConfig{Host: "10.0.0.1", Username: "root", Port: "22"}
+
+	valid := map[string]string{
+		"port":          "2222",
+		"tags":          "web, prod,web",
+		"forward_agent": "yes",
+		"proxy_jump":    "bastion,admin@10.0.0.254:2200",
+		"notes":         "primary database",
+	}
+	for name, value := range valid {
+		if err := cfg.SetField(name, value); err != nil {
+			t.Errorf("SetField(%s, %q) error = %v", name, value, err)
+		}
+	}
+	if cfg.Port != "2222" || !cfg.ForwardAgent || cfg.Notes != "primary database" {
+		t.Errorf("SetField() = %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Tags, []string{"prod", "web"}) {
+		t.Errorf("Tags = %v, want [prod web]", cfg.Tags)
+	}
+
+	invalid := [][2]string{
+		{"port", "0"},
+		{"port", "ssh"},
+		{"alias", "a@b"},
+		{"tags", "ok,no way"},
+		{"forward_agent", "maybe"},
+		{"proxy_jump", "bastion,"},
+		{"proxy_jump", "bastion:99999"},
+		{"host", "root@10.0.0.1"},
+		{"last_used", "yesterday"},
+		{"nosuch", "1"},
+	}
+	for _, field := range invalid {
+		if err := cfg.SetField(field[0], field[1]); err == nil {
+			t.Errorf("SetField(%s, %q) expected an error", field[0], field[1])
+		}
+	}
+	if cfg.Port != "2222" {
+		t.Errorf("Port = %s after invalid values, want 2222", cfg.Port)
+	}
+}
+
+func TestUnsetField(t *testing.T) {
+	cfg := SSHConfig{Host: "10.0.0.1", Username: "root", Port: "22", Tags: []string{"web"}, IdentitiesOnly: true}
+
+	for _, name := range []string{"tags", "identities_only"} {
+		if err := cfg.UnsetField(name); err != nil {
+			t.Errorf("UnsetField(%s) error = %v", name, err)
+		}
+	}
+	if cfg.Tags != nil || cfg.IdentitiesOnly {
+		t.Errorf("UnsetField() = %+v", cfg)
+	}
+
+	for _, name := range []string{"host", "port", "nosuch"} {
+		if err := cfg.UnsetField(name); err == nil {
+			t.Errorf("UnsetField(%s) expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+// pkg/config/file.go
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+// corruptError 配置文件无法解析
+type corruptError struct {
+	err error
+}
+
+func (e *corruptError) Error() string {
+	return fmt.Sprintf("failed to parse config file: %v", e.err)
+}
+
+// FileStore 保存在 JSON 文件中的配置。修改时持有文件锁并原子写入，
+// 多个 ssm 进程同时修改配置时不会互相覆盖；配置文件损坏时从备份恢复，旧版本格式自动升级
+type FileStore struct {
+	path string
+}
+
+// NewFileStore 创建使用指定配置文件的存储，文件不存在时视为空配置
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Path 配置文件路径
+func (f *FileStore) Path() string {
+	return f.path
+}
+
+// Load 加载全部配置
+func (f *FileStore) Load() (*ConfigStore, error) {
+	return f.load(false)
+}
+
+// Save 保存全部配置（持有文件锁，原子写入）
+func (f *FileStore) Save(store *ConfigStore) error {
+	unlock, err := lockConfig(f.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return save(f.path, store)
+}
+
+// Update 在文件锁内完成读取、修改、写入，用于一次修改多个配置
+func (f *FileStore) Update(fn func(store *ConfigStore) error) error {
+	unlock, err := lockConfig(f.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	store, err := f.load(true)
+	if err != nil {
+		return err
+	}
+	if err := fn(store); err != nil {
+		return err
+	}
+	return save(f.path, store)
+}
+
+// Get 获取配置
+func (f *FileStore) Get(key string) (*SSHConfig, bool) {
+	store, err := f.Load()
+	if err != nil {
+		return nil, false
+	}
+
+	config, exists := store.Items[key]
+	if !exists {
+		return nil, false
+	}
+	return &config, true
+}
+
+// Put 保存单个配置
+func (f *FileStore) Put(config *SSHConfig) error {
+	return f.Update(func(store *ConfigStore) error {
+		return store.put(*config)
+	})
+}
+
+// Delete 删除配置
+func (f *FileStore) Delete(key string) error {
+	return f.Update(func(store *ConfigStore) error {
+		return store.remove(key)
+	})
+}
+
+// Move 在文件锁内移动配置，删除和写入一次保存
+func (f *FileStore) Move(key string, config *SSHConfig) ([]string, error) {
+	var updated []string
+	err := f.Update(func(store *ConfigStore) error {
+		var err error
+		updated, err = store.move(key, *config)
+		return err
+	})
+	return updated, err
+}
+
+// List 列出所有配置
+func (f *FileStore) List() ([]SSHConfig, error) {
+	store, err := f.Load()
+	if err != nil {
+		return nil, err
+	}
+	return store.list(), nil
+}
+
+// watchInterval 检查配置文件是否变化的间隔
+var watchInterval = time.Second
+
+// Watch 定期检查配置文件，包括其他 ssm 进程写入的变化
+func (f *FileStore) Watch(ctx context.Context) (<-chan Event, error) {
+	return watchChanges(ctx, func() (map[string]SSHConfig, <-chan struct{}, error) {
+		next := make(chan struct{})
+		time.AfterFunc(watchInterval, func() { close(next) })
+
+		store, _, err := readStore(f.path)
+		if err != nil {
+			return nil, next, err
+		}
+		return store.Items, next, nil
+	})
+}
+
+// load 加载配置，locked 表示调用方已持有文件锁。
+// 配置文件损坏时从备份恢复，旧版本格式的文件升级后写回
+func (f *FileStore) load(locked bool) (*ConfigStore, error) {
+	store, version, err := readStore(f.path)
+
+	var corrupt *corruptError
+	isCorrupt := errors.As(err, &corrupt)
+	if err != nil && !isCorrupt {
+		return nil, err
+	}
+	if !isCorrupt && version == CurrentVersion {
+		return store, nil
+	}
+
+	if !locked {
+		unlock, err := lockConfig(f.path)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+	if isCorrupt {
+		return recoverFromBackup(f.path)
+	}
+	return upgradeFile(f.path)
+}
+
+// readStore 读取并解析配置文件，返回升级到当前格式的配置和文件原来的版本，文件不存在时返回空配置
+func readStore(path string) (*ConfigStore, int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ConfigStore{Version: CurrentVersion, Items: make(map[string]SSHConfig)}, CurrentVersion, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read config file: %v", err)
+	}
+	return decodeStore(data)
+}
+
+// recoverFromBackup 配置文件损坏时从备份恢复，损坏的文件保留为 .corrupt（调用方需持有文件锁）
+func recoverFromBackup(configFile string) (*ConfigStore, error) {
+	// 获取锁之后重新读取，其他进程可能已经恢复了配置文件
+	store, _, err := readStore(configFile)
+	var corrupt *corruptError
+	if !errors.As(err, &corrupt) {
+		return store, err
+	}
+
+	backupFile := getBackupFilePath(configFile)
+	backup, err := os.ReadFile(backupFile)
+	if err != nil {
+		return nil, fmt.Errorf("%v (no usable backup: %v)", corrupt, err)
+	}
+	store, _, err = readStore(backupFile)
+	if err != nil {
+		return nil, fmt.Errorf("%v (backup is also unusable: %v)", corrupt, err)
+	}
+
+	if data, err := os.ReadFile(configFile); err == nil {
+		_ = utils.WriteFileAtomic(configFile+".corrupt", data, 0600)
+	}
+	if err := utils.WriteFileAtomic(configFile, backup, 0600); err != nil {
+		return nil, fmt.Errorf("%v (failed to restore backup: %v)", corrupt, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: %s could not be parsed (%v), restored from %s (damaged file kept as %s.corrupt)\n",
+		configFile, corrupt.err, backupFile, configFile)
+	return store, nil
+}
+
+// save 保存配置（调用方需持有文件锁），覆盖前将当前完好的配置保存为备份
+func save(configFile string, store *ConfigStore) error {
+	if err := os.MkdirAll(filepath.Dir(configFile), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	store.Version = CurrentVersion
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %v", err)
+	}
+
+	if current, err := os.ReadFile(configFile); err == nil && json.Valid(current) {
+		// 不覆盖更新版本的 ssm 写入的配置
+		if _, _, err := decodeStore(current); errors.Is(err, ErrNewerVersion) {
+			return err
+		}
+		if err := utils.WriteFileAtomic(getBackupFilePath(configFile), current, 0600); err != nil {
+			return fmt.Errorf("failed to back up config file: %v", err)
+		}
+	}
+
+	return utils.WriteFileAtomic(configFile, data, 0600)
+}
+
+// lockConfig 获取配置文件的排他锁（advisory，各 ssm 进程之间生效），返回释放函数
+func lockConfig(configFile string) (func(), error) {
+	lockFile := configFile + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockFile), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config lock: %v", err)
+	}
+
+	for {
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX)
+		if err != unix.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock config: %v", err)
+	}
+
+	return func() {
+		unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// getBackupFilePath 获取配置备份文件路径
+func getBackupFilePath(configFile string) string {
+	return configFile + ".bak"
+}
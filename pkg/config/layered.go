@@ -0,0 +1,121 @@
+// pkg/config/layered.go
+package config
+
+import (
+	"context"
+	"sort"
+)
+
+// LayeredStore 按顺序叠加多个存储的只读视图，同一配置键以靠前的存储为准
+type LayeredStore struct {
+	layers []Store
+}
+
+// NewLayeredStore 创建只读的叠加存储，layers 按优先级从高到低排列
+func NewLayeredStore(layers ...Store) *LayeredStore {
+	return &LayeredStore{layers: layers}
+}
+
+// Get 返回第一个包含该配置键的存储中的配置
+func (l *LayeredStore) Get(key string) (*SSHConfig, bool) {
+	items, err := l.items()
+	if err != nil {
+		return nil, false
+	}
+
+	config, exists := items[key]
+	if !exists {
+		return nil, false
+	}
+	return &config, true
+}
+
+// Put 叠加存储是只读的
+func (l *LayeredStore) Put(config *SSHConfig) error {
+	return ErrReadOnly
+}
+
+// Delete 叠加存储是只读的
+func (l *LayeredStore) Delete(key string) error {
+	return ErrReadOnly
+}
+
+// Move 叠加存储是只读的
+func (l *LayeredStore) Move(key string, config *SSHConfig) ([]string, error) {
+	return nil, ErrReadOnly
+}
+
+// List 合并所有存储的配置
+func (l *LayeredStore) List() ([]SSHConfig, error) {
+	items, err := l.items()
+	if err != nil {
+		return nil, err
+	}
+	store := ConfigStore{Items: items}
+	return store.list(), nil
+}
+
+// Watch 任一存储变化时重新合并，只发送合并后可见的变化
+func (l *LayeredStore) Watch(ctx context.Context) (<-chan Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	changed := make(chan struct{}, 1)
+	for _, layer := range l.layers {
+		events, err := layer.Watch(ctx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		go func() {
+			for range events {
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	}
+
+	events, err := watchChanges(ctx, func() (map[string]SSHConfig, <-chan struct{}, error) {
+		items, err := l.items()
+		return items, changed, err
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+	return events, nil
+}
+
+// items 合并所有存储的配置，靠前的存储优先；别名已被更靠前的配置使用时忽略
+func (l *LayeredStore) items() (map[string]SSHConfig, error) {
+	items := make(map[string]SSHConfig)
+	aliases := make(map[string]bool)
+	for _, layer := range l.layers {
+		configs, err := layer.List()
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Slice(configs, func(i, j int) bool {
+			return configs[i].GetKey() < configs[j].GetKey()
+		})
+		for _, config := range configs {
+			key := config.GetKey()
+			if _, exists := items[key]; exists {
+				continue
+			}
+			if aliases[config.Alias] {
+				config.Alias = ""
+			}
+			if config.Alias != "" {
+				aliases[config.Alias] = true
+			}
+			items[key] = config
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,108 @@
+// pkg/config/memory.go
+package config
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore 保存在内存中的配置，不读写任何文件，用于测试和临时配置
+type MemoryStore struct {
+	mu      sync.Mutex
+	store   ConfigStore
+	changed chan struct{} // 每次修改后关闭并替换，通知 Watch
+}
+
+// NewMemoryStore 创建内存配置存储，configs 为初始配置
+func NewMemoryStore(configs ...SSHConfig) *MemoryStore {
+	m := &MemoryStore{
+		store:   ConfigStore{Version: CurrentVersion, Items: make(map[string]SSHConfig)},
+		changed: make(chan struct{}),
+	}
+	for _, config := range configs {
+		m.store.Items[config.GetKey()] = config.clone()
+	}
+	return m
+}
+
+// Get 获取配置
+func (m *MemoryStore) Get(key string) (*SSHConfig, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	config, exists := m.store.Items[key]
+	if !exists {
+		return nil, false
+	}
+	config = config.clone()
+	return &config, true
+}
+
+// Put 保存配置
+func (m *MemoryStore) Put(config *SSHConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.store.put(config.clone()); err != nil {
+		return err
+	}
+	m.notify()
+	return nil
+}
+
+// Delete 删除配置
+func (m *MemoryStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.store.remove(key); err != nil {
+		return err
+	}
+	m.notify()
+	return nil
+}
+
+// Move 移动配置
+func (m *MemoryStore) Move(key string, config *SSHConfig) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	updated, err := m.store.move(key, config.clone())
+	if err != nil {
+		return nil, err
+	}
+	m.notify()
+	return updated, nil
+}
+
+// List 列出所有配置
+func (m *MemoryStore) List() ([]SSHConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	configs := m.store.list()
+	for i := range configs {
+		configs[i] = configs[i].clone()
+	}
+	return configs, nil
+}
+
+// Watch 监听 Put 和 Delete 产生的变化
+func (m *MemoryStore) Watch(ctx context.Context) (<-chan Event, error) {
+	return watchChanges(ctx, func() (map[string]SSHConfig, <-chan struct{}, error) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		items := make(map[string]SSHConfig, len(m.store.Items))
+		for key, config := range m.store.Items {
+			items[key] = config.clone()
+		}
+		return items, m.changed, nil
+	})
+}
+
+// notify 通知所有 Watch 配置已变化（调用方需持有锁）
+func (m *MemoryStore) notify() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
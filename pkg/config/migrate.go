@@ -0,0 +1,126 @@
+// pkg/config/migrate.go
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+// CurrentVersion 当前配置文件格式版本（没有 version 字段的旧文件视为版本 1）
+const CurrentVersion = 2
+
+// ErrNewerVersion 配置文件由更新版本的 ssm 写入
+var ErrNewerVersion = errors.New("config file was written by a newer version of ssm")
+
+// migration 将配置从 version-1 升级到 version
+type migration struct {
+	version     int
+	description string
+	migrate     func(items map[string]map[string]any) error
+}
+
+// migrations 按版本顺序排列的升级步骤，每次只升级一个版本
+var migrations = []migration{
+	{
+		version:     2,
+		description: "add format version, drop the implicit ~/.ssh/id_rsa recorded when no -i was given",
+		migrate:     migrateDefaultPrivateKey,
+	},
+}
+
+// decodeStore 解析配置文件内容，旧版本格式逐步升级到当前版本，返回文件原来的版本
+func decodeStore(data []byte) (*ConfigStore, int, error) {
+	var raw struct {
+		Version int                       `json:"version"`
+		Items   map[string]map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, &corruptError{err: err}
+	}
+
+	version := raw.Version
+	if version == 0 {
+		version = 1
+	}
+	if version > CurrentVersion {
+		return nil, version, fmt.Errorf("%w (format version %d, this ssm supports up to version %d), please upgrade ssm",
+			ErrNewerVersion, version, CurrentVersion)
+	}
+
+	if version < CurrentVersion {
+		if raw.Items == nil {
+			raw.Items = make(map[string]map[string]any)
+		}
+		for _, m := range migrations {
+			if m.version <= version {
+				continue
+			}
+			if err := m.migrate(raw.Items); err != nil {
+				return nil, version, fmt.Errorf("failed to migrate config to version %d: %v", m.version, err)
+			}
+		}
+		// 升级后的内容按当前格式重新解析
+		migrated, err := json.Marshal(map[string]any{"version": CurrentVersion, "items": raw.Items})
+		if err != nil {
+			return nil, version, fmt.Errorf("failed to migrate config: %v", err)
+		}
+		data = migrated
+	}
+
+	var store ConfigStore
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, version, &corruptError{err: err}
+	}
+	if store.Items == nil {
+		store.Items = make(map[string]SSHConfig)
+	}
+	store.Version = CurrentVersion
+	return &store, version, nil
+}
+
+// upgradeFile 将旧版本的配置文件升级为当前版本，原文件备份为 <文件>.v<版本>.bak（调用方需持有文件锁）
+func upgradeFile(configFile string) (*ConfigStore, error) {
+	// 获取锁之后重新读取，其他进程可能已经完成升级
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	store, version, err := decodeStore(data)
+	if err != nil || version == CurrentVersion {
+		return store, err
+	}
+
+	backupFile := fmt.Sprintf("%s.v%d.bak", configFile, version)
+	if err := utils.WriteFileAtomic(backupFile, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to back up config file before migration: %v", err)
+	}
+	if err := save(configFile, store); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(os.Stderr, "Upgraded %s from format version %d to %d (backup: %s)\n",
+		configFile, version, CurrentVersion, filepath.Base(backupFile))
+	for _, m := range migrations {
+		if m.version > version {
+			fmt.Fprintf(os.Stderr, "  v%d: %s\n", m.version, m.description)
+		}
+	}
+	return store, nil
+}
+
+// migrateDefaultPrivateKey 旧版本在未指定 -i 时总是记录 ~/.ssh/id_rsa，清除后改为自动查找
+// ssh-agent 与默认私钥（其中包括 ~/.ssh/id_rsa）。设置了 identities_only 的配置只使用指定的私钥，保持不变
+func migrateDefaultPrivateKey(items map[string]map[string]any) error {
+	defaultKey := filepath.Join(os.Getenv("HOME"), ".ssh", "id_rsa")
+	for _, item := range items {
+		if item["private_key"] == defaultKey && item["identities_only"] != true {
+			delete(item, "private_key")
+		}
+	}
+	return nil
+}
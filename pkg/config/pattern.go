@@ -0,0 +1,283 @@
+// pkg/config/pattern.go
+package config
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+// inheritableFields 可以从模式继承的字段（JSON 名称）。
+// 用户名和端口只在主机参数中未指定时使用模式的设置；别名、分组、标签、密码不继承
+var inheritableFields = []string{
+	"private_key",
+	"certificate",
+	"identities_only",
+	"forward_agent",
+	"proxy_jump",
+	"ciphers",
+	"macs",
+	"kex_algorithms",
+	"host_key_algorithms",
+}
+
+// isInheritable 字段是否可以从模式继承
+func isInheritable(name string) bool {
+	return slices.Contains(inheritableFields, name)
+}
+
+// TrackOverride 记录主机上显式设置的可继承字段：值为空或 false 时记为覆盖，不再从模式继承，
+// 否则去掉记录。SetField 和 UnsetField 已自动处理，直接修改字段后调用
+func (c *SSHConfig) TrackOverride(name string) {
+	if c.IsPattern() || !isInheritable(name) {
+		return
+	}
+	c.removeOverride(name)
+	if field, _ := c.fieldValue(name); field.IsZero() {
+		c.Overrides = append(c.Overrides, name)
+		sort.Strings(c.Overrides)
+	}
+}
+
+// removeOverride 去掉字段的覆盖记录，字段重新从模式继承
+func (c *SSHConfig) removeOverride(name string) {
+	c.Overrides = slices.DeleteFunc(c.Overrides, func(override string) bool { return override == name })
+	if len(c.Overrides) == 0 {
+		c.Overrides = nil
+	}
+}
+
+// IsPattern 主机名是否为模式：包含 * 或 ? 的通配符，或 CIDR 网段
+func IsPattern(host string) bool {
+	if strings.ContainsAny(host, "*?") {
+		return true
+	}
+	_, _, err := net.ParseCIDR(host)
+	return err == nil
+}
+
+// IsPattern 是否为模式配置，模式配置的字段作为匹配主机的默认值
+func (c *SSHConfig) IsPattern() bool {
+	return IsPattern(c.Host)
+}
+
+// ValidatePattern 检查模式格式
+func ValidatePattern(pattern string) error {
+	if !IsPattern(pattern) {
+		return fmt.Errorf("invalid pattern %q: use a glob with * or ? (e.g. *.corp.example.com) or a CIDR (e.g. 10.0.0.0/8)", pattern)
+	}
+	if strings.ContainsAny(pattern, "@: \t") && !strings.Contains(pattern, "/") {
+		return fmt.Errorf("invalid pattern %q: a pattern matches host names only", pattern)
+	}
+	return nil
+}
+
+// MatchPattern 主机名是否匹配模式。通配符不区分大小写，CIDR 只匹配 IP 地址
+func MatchPattern(pattern, host string) bool {
+	if _, network, err := net.ParseCIDR(pattern); err == nil {
+		ip := net.ParseIP(host)
+		return ip != nil && network.Contains(ip)
+	}
+	return utils.WildcardMatch(strings.ToLower(pattern), strings.ToLower(host))
+}
+
+// SplitPatterns 将配置分为具体主机和模式
+func SplitPatterns(configs []SSHConfig) (hosts, patterns []SSHConfig) {
+	for _, config := range configs {
+		if config.IsPattern() {
+			patterns = append(patterns, config)
+		} else {
+			hosts = append(hosts, config)
+		}
+	}
+	return hosts, patterns
+}
+
+// SortPatterns 按优先级排序模式：CIDR 在通配符之前，CIDR 前缀越长越优先，
+// 通配符中非通配字符越多越优先，相同时按模式文本排序
+func SortPatterns(patterns []SSHConfig) {
+	sort.SliceStable(patterns, func(i, j int) bool {
+		ci, si := patternRank(patterns[i].Host)
+		cj, sj := patternRank(patterns[j].Host)
+		if ci != cj {
+			return ci
+		}
+		if si != sj {
+			return si > sj
+		}
+		return patterns[i].Host < patterns[j].Host
+	})
+}
+
+// MatchingPatterns 获取匹配主机名的模式，按优先级排序
+func MatchingPatterns(s Store, host string) []SSHConfig {
+	configs, err := s.List()
+	if err != nil {
+		return nil
+	}
+
+	_, patterns := SplitPatterns(configs)
+	var matched []SSHConfig
+	for _, pattern := range patterns {
+		if MatchPattern(pattern.Host, host) {
+			matched = append(matched, pattern)
+		}
+	}
+	SortPatterns(matched)
+	return matched
+}
+
+// ResolveAddress 解析 [user@]hostname[:port]，未指定的用户和端口依次使用匹配模式的设置、默认值。
+// origins 记录用户名和端口的来源
+func ResolveAddress(s Store, host string) (username, hostname, port string, origins map[string]string) {
+	username, hostname, port = utils.SplitSSHHost(host)
+	origins = map[string]string{"host": "argument", "username": "argument", "port": "argument"}
+	patterns := MatchingPatterns(s, hostname)
+
+	if username == "" {
+		username, origins["username"] = fromPatterns(patterns, "username")
+		if username == "" {
+			username, origins["username"] = utils.GetDefaultUsername(""), "default"
+		}
+	}
+	if port == "" {
+		port, origins["port"] = fromPatterns(patterns, "port")
+		if port == "" {
+			port, origins["port"] = utils.GetDefaultPort(""), "default"
+		}
+	}
+	return username, hostname, port, origins
+}
+
+// ApplyPatterns 将匹配模式的设置合并到配置中未设置（且未显式覆盖）的字段，返回继承的字段及来源。
+// 继承的值只在本次连接中使用，保存配置时不会写入
+func ApplyPatterns(s Store, cfg *SSHConfig) map[string]string {
+	origins := make(map[string]string)
+	if cfg.IsPattern() {
+		return origins
+	}
+
+	patterns := MatchingPatterns(s, cfg.Host)
+	inherited := make(map[string]any)
+	for _, name := range inheritableFields {
+		field, _ := cfg.fieldValue(name)
+		if !field.IsZero() || slices.Contains(cfg.Overrides, name) {
+			continue
+		}
+
+		for _, pattern := range patterns {
+			value, _ := pattern.fieldValue(name)
+			if value.IsZero() {
+				continue
+			}
+			// 跳板机链经过主机自身时不继承，避免 bastion 通过自己连接
+			if name == "proxy_jump" && jumpsThrough(s, value.String(), cfg.Host) {
+				continue
+			}
+			field.Set(value)
+			inherited[name] = value.Interface()
+			origins[name] = "pattern " + pattern.Host
+			break
+		}
+	}
+
+	if len(inherited) > 0 {
+		cfg.inherited = inherited
+	}
+	return origins
+}
+
+// withoutInherited 去掉从模式继承且未被修改的字段，得到需要保存的配置
+func (c SSHConfig) withoutInherited() SSHConfig {
+	for name, value := range c.inherited {
+		field, _ := c.fieldValue(name)
+		if reflect.DeepEqual(field.Interface(), value) {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+	c.inherited = nil
+	return c
+}
+
+// Resolution 主机的有效配置
+type Resolution struct {
+	Config   *SSHConfig
+	Saved    bool              // 是否有已保存的配置
+	Origins  map[string]string // 字段（JSON 名称）-> 来源
+	Patterns []SSHConfig       // 匹配的模式，按优先级排序
+}
+
+// Resolve 计算主机（别名或 [user@]hostname[:port]）的有效配置：
+// 已保存的配置优先，未设置的字段从匹配的模式继承，没有保存的配置时新建（未保存）
+func Resolve(s Store, host string) *Resolution {
+	var cfg *SSHConfig
+	origins := make(map[string]string)
+	if aliased, exists := GetByAlias(s, host); exists {
+		cfg = aliased
+		for _, name := range []string{"host", "username", "port"} {
+			origins[name] = "alias " + host
+		}
+	} else {
+		var username, hostname, port string
+		username, hostname, port, origins = ResolveAddress(s, host)
+		cfg = &SSHConfig{Host: hostname, Username: username, Port: port}
+	}
+
+	resolution := &Resolution{Config: cfg, Origins: origins}
+	if saved, exists := s.Get(cfg.GetKey()); exists {
+		*cfg = *saved
+		resolution.Saved = true
+		for _, name := range FieldNames() {
+			if _, set := origins[name]; !set && cfg.FieldString(name) != "" {
+				origins[name] = "saved " + cfg.GetKey()
+			}
+		}
+	}
+
+	for name, origin := range ApplyPatterns(s, cfg) {
+		origins[name] = origin
+	}
+	resolution.Patterns = MatchingPatterns(s, cfg.Host)
+	return resolution
+}
+
+// fromPatterns 获取第一个设置了该字段的模式的值和来源
+func fromPatterns(patterns []SSHConfig, name string) (string, string) {
+	for _, pattern := range patterns {
+		if value := pattern.FieldString(name); value != "" {
+			return value, "pattern " + pattern.Host
+		}
+	}
+	return "", ""
+}
+
+// jumpsThrough 跳板机链是否经过该主机
+func jumpsThrough(s Store, proxyJump, host string) bool {
+	for _, hop := range strings.Split(proxyJump, ",") {
+		hop = strings.TrimSpace(hop)
+		hopHost := ""
+		if aliased, exists := GetByAlias(s, hop); exists {
+			hopHost = aliased.Host
+		} else {
+			_, hopHost, _ = utils.SplitSSHHost(hop)
+		}
+		if strings.EqualFold(hopHost, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// patternRank 模式的优先级：是否为 CIDR，以及 CIDR 前缀长度或通配符中非通配字符的数量
+func patternRank(pattern string) (bool, int) {
+	if _, network, err := net.ParseCIDR(pattern); err == nil {
+		ones, _ := network.Mask.Size()
+		return true, ones
+	}
+	return false, len(pattern) - strings.Count(pattern, "*") - strings.Count(pattern, "?")
+}
@@ -0,0 +1,179 @@
+// pkg/config/pattern_test.go
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, host string
+		want          bool
+	}{
+		{"*.corp.example.com", "web1.corp.example.com", true},
+		{"*.corp.example.com", "WEB1.Corp.Example.com", true},
+		{"*.corp.example.com", "corp.example.com", false},
+		{"web?", "web1", true},
+		{"web?", "web12", false},
+		{"10.0.0.0/8", "10.1.2.3", true},
+		{"10.0.0.0/8", "192.168.1.1", false},
+		{"10.0.0.0/8", "ten.example.com", false},
+		{"*", "anything", true},
+	}
+	for _, tt := range tests {
+		if got := MatchPattern(tt.pattern, tt.host); got != tt.want {
+			t.Errorf("MatchPattern(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.want)
+		}
+	}
+
+	for _, pattern := range []string{"web.example.com", "root@*.corp", "*.corp:22"} {
+		if err := ValidatePattern(pattern); err == nil {
+			t.Errorf("ValidatePattern(%q) expected an error", pattern)
+		}
+	}
+}
+
+func TestMatchingPatternsPrecedence(t *testing.T) {
+	store := NewMemoryStore(
+		SSHConfig{Host: "*"},
+		SSHConfig{Host: "*.example.com"},
+		SSHConfig{Host: "*.corp.example.com"},
+		SSHConfig{Host: "10.0.0.0/8"},
+		SSHConfig{Host: "10.1.0.0/16"},
+		SSHConfig{Host: "10.1.2.3", Username: "root", Port: "22"},
+	)
+
+	want := []string{"10.1.0.0/16", "10.0.0.0/8", "*"}
+	got := MatchingPatterns(store, "10.1.2.3")
+	if len(got) != len(want) {
+		t.Fatalf("MatchingPatterns() returned %d patterns, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Host != want[i] {
+			t.Errorf("pattern %d = %s, want %s", i, got[i].Host, want[i])
+		}
+	}
+
+	got = MatchingPatterns(store, "web.corp.example.com")
+	if len(got) != 3 || got[0].Host != "*.corp.example.com" || got[1].Host != "*.example.com" {
+		t.Errorf("MatchingPatterns() = %v, want the most specific glob first", got)
+	}
+}
+
+func TestApplyPatterns(t *testing.T) {
+	store := NewMemoryStore(
+		SSHConfig{Host: "*.corp", Username: "deploy", Port: "2222", PrivateKey: "/keys/corp", ProxyJump: "bastion.corp", ForwardAgent: true},
+		SSHConfig{Host: "*", PrivateKey: "/keys/default", Ciphers: "aes256-ctr"},
+	)
+
+	username, hostname, port, origins := ResolveAddress(store, "web.corp")
+	if username != "deploy" || hostname != "web.corp" || port != "2222" {
+		t.Errorf("ResolveAddress() = %s, %s, %s, want deploy, web.corp, 2222", username, hostname, port)
+	}
+	if origins["username"] != "pattern *.corp" {
+		t.Errorf("username origin = %q, want pattern *.corp", origins["username"])
+	}
+	if username, _, _, _ = ResolveAddress(store, "admin@web.corp"); username != "admin" {
+		t.Errorf("ResolveAddress() username = %s, want the one from the argument", username)
+	}
+
+	cfg := &SSHConfig{Host: "web.corp", Username: "deploy", Port: "2222", Ciphers: "chacha20-poly1305@openssh.com"}
+	origins = ApplyPatterns(store, cfg)
+	if cfg.PrivateKey != "/keys/corp" || cfg.ProxyJump != "bastion.corp" || !cfg.ForwardAgent {
+		t.Errorf("ApplyPatterns() did not merge the most specific pattern: %+v", cfg)
+	}
+	if cfg.Ciphers != "chacha20-poly1305@openssh.com" || origins["ciphers"] != "" {
+		t.Errorf("ApplyPatterns() overrode a field set on the host: %s", cfg.Ciphers)
+	}
+	if origins["private_key"] != "pattern *.corp" {
+		t.Errorf("private_key origin = %q, want pattern *.corp", origins["private_key"])
+	}
+
+	// 跳板机自身不通过自己连接
+	bastion := &SSHConfig{Host: "bastion.corp", Username: "deploy", Port: "2222"}
+	ApplyPatterns(store, bastion)
+	if bastion.ProxyJump != "" {
+		t.Errorf("bastion inherited a jump chain through itself: %s", bastion.ProxyJump)
+	}
+}
+
+func TestInheritedFieldsAreNotSaved(t *testing.T) {
+	store := NewMemoryStore(SSHConfig{Host: "*.corp", PrivateKey: "/keys/corp", ProxyJump: "bastion"})
+
+	cfg := &SSHConfig{Host: "web.corp", Username: "deploy", Port: "22"}
+	ApplyPatterns(store, cfg)
+	cfg.ProxyJump = "other-bastion"
+	if err := store.Put(cfg); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	saved, _ := store.Get(cfg.GetKey())
+	if saved.PrivateKey != "" {
+		t.Errorf("inherited private key was saved: %s", saved.PrivateKey)
+	}
+	if saved.ProxyJump != "other-bastion" {
+		t.Errorf("changed jump chain was not saved: %q", saved.ProxyJump)
+	}
+
+	resolution := Resolve(store, "deploy@web.corp")
+	if !resolution.Saved || resolution.Config.PrivateKey != "/keys/corp" {
+		t.Errorf("Resolve() = %+v, want the saved config with the inherited key", resolution.Config)
+	}
+	if resolution.Origins["proxy_jump"] != "saved deploy@web.corp:22" {
+		t.Errorf("proxy_jump origin = %q", resolution.Origins["proxy_jump"])
+	}
+}
+
+func TestOverridePatternWithFalseAndNone(t *testing.T) {
+	store := NewMemoryStore(SSHConfig{Host: "*.corp", ProxyJump: "bastion", ForwardAgent: true, IdentitiesOnly: true, PrivateKey: "/keys/corp"})
+
+	// 与 -A=false、-J none 相同：继承后显式关闭
+	cfg := &SSHConfig{Host: "web.corp", Username: "deploy", Port: "22"}
+	ApplyPatterns(store, cfg)
+	cfg.ForwardAgent = false
+	cfg.TrackOverride("forward_agent")
+	if err := cfg.SetField("proxy_jump", "none"); err != nil {
+		t.Fatalf("SetField(proxy_jump, none) error = %v", err)
+	}
+	if err := store.Put(cfg); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	saved, _ := store.Get(cfg.GetKey())
+	if !reflect.DeepEqual(saved.Overrides, []string{"forward_agent", "proxy_jump"}) {
+		t.Errorf("saved overrides = %v, want [forward_agent proxy_jump]", saved.Overrides)
+	}
+
+	resolved := Resolve(store, "deploy@web.corp").Config
+	if resolved.ForwardAgent || resolved.ProxyJump != "" {
+		t.Errorf("Resolve() = %+v, want agent forwarding and the jump host switched off", resolved)
+	}
+	if !resolved.IdentitiesOnly || resolved.PrivateKey != "/keys/corp" {
+		t.Errorf("Resolve() = %+v, want the fields that are not overridden inherited", resolved)
+	}
+
+	// 重新开启或清空字段后去掉覆盖记录
+	if err := saved.SetField("forward_agent", "yes"); err != nil {
+		t.Fatalf("SetField(forward_agent, yes) error = %v", err)
+	}
+	if err := saved.UnsetField("proxy_jump"); err != nil {
+		t.Fatalf("UnsetField(proxy_jump) error = %v", err)
+	}
+	if saved.Overrides != nil {
+		t.Errorf("overrides = %v after switching the fields back, want none", saved.Overrides)
+	}
+	ApplyPatterns(store, saved)
+	if !saved.ForwardAgent || saved.ProxyJump != "bastion" {
+		t.Errorf("ApplyPatterns() = %+v, want the jump host inherited again", saved)
+	}
+
+	// 模式本身不记录覆盖，只有可继承的字段可以覆盖
+	pattern, _ := store.Get("*.corp")
+	if err := pattern.SetField("forward_agent", "no"); err != nil || pattern.Overrides != nil {
+		t.Errorf("pattern overrides = %v, %v, want none", pattern.Overrides, err)
+	}
+	if err := saved.SetField("overrides", "username"); err == nil {
+		t.Error("SetField(overrides, username) expected an error")
+	}
+}
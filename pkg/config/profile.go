@@ -0,0 +1,118 @@
+// pkg/config/profile.go
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+// DefaultProfile 默认配置，使用 ssh_config.json
+const DefaultProfile = "default"
+
+// ValidateProfile 检查配置名称格式
+func ValidateProfile(name string) error {
+	if !aliasPattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' and '-' only", name)
+	}
+	return nil
+}
+
+// GetProfilePath 获取配置的文件路径，默认配置为 ssh_config.json，其他配置保存在 profiles/<名称>.json
+func GetProfilePath(name string) string {
+	if name == DefaultProfile {
+		return GetConfigFilePath()
+	}
+	return filepath.Join(getProfilesDir(), name+".json")
+}
+
+// ListProfiles 列出所有配置，默认配置在最前
+func ListProfiles() ([]string, error) {
+	entries, err := os.ReadDir(getProfilesDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read profiles: %v", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if entry.IsDir() || name == entry.Name() || name == DefaultProfile || ValidateProfile(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...), nil
+}
+
+// GetActiveProfile 获取 'ssm profile use' 选择的配置，未选择时为默认配置
+func GetActiveProfile() string {
+	data, err := os.ReadFile(getActiveProfileFile())
+	if err != nil {
+		return DefaultProfile
+	}
+
+	name := strings.TrimSpace(string(data))
+	if ValidateProfile(name) != nil {
+		return DefaultProfile
+	}
+	return name
+}
+
+// SetActiveProfile 选择之后默认使用的配置
+func SetActiveProfile(name string) error {
+	if err := ValidateProfile(name); err != nil {
+		return err
+	}
+
+	path := getActiveProfileFile()
+	if name == DefaultProfile {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to reset active profile: %v", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	if err := utils.WriteFileAtomic(path, []byte(name+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to save active profile: %v", err)
+	}
+	return nil
+}
+
+// ResolveConfigFile 确定本次使用的配置文件，优先级从高到低：
+// --config、--profile、环境变量 SSM_CONFIG、'ssm profile use' 选择的配置、默认配置
+func ResolveConfigFile(configFlag, profileFlag string) (string, error) {
+	if configFlag != "" && profileFlag != "" {
+		return "", fmt.Errorf("--config and --profile cannot be used together")
+	}
+	if configFlag != "" {
+		return configFlag, nil
+	}
+	if profileFlag != "" {
+		if err := ValidateProfile(profileFlag); err != nil {
+			return "", err
+		}
+		return GetProfilePath(profileFlag), nil
+	}
+	if path := os.Getenv("SSM_CONFIG"); path != "" {
+		return path, nil
+	}
+	return GetProfilePath(GetActiveProfile()), nil
+}
+
+// getProfilesDir 获取保存命名配置的目录
+func getProfilesDir() string {
+	return filepath.Join(GetConfigDir(), "profiles")
+}
+
+// getActiveProfileFile 获取记录当前配置名称的文件路径
+func getActiveProfileFile() string {
+	return filepath.Join(GetConfigDir(), "profile")
+}
@@ -0,0 +1,83 @@
+// pkg/config/profile_test.go
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveConfigFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("SSM_HOME", home)
+	t.Setenv("SSM_CONFIG", "")
+
+	if err := SetActiveProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	work := filepath.Join(home, "profiles", "work.json")
+
+	tests := []struct {
+		name                    string
+		configFlag, profileFlag string
+		env                     string
+		want                    string
+	}{
+		{"active profile", "", "", "", work},
+		{"environment", "", "", "/env.json", "/env.json"},
+		{"profile flag", "", "home", "/env.json", filepath.Join(home, "profiles", "home.json")},
+		{"default profile flag", "", DefaultProfile, "", filepath.Join(home, "ssh_config.json")},
+		{"config flag", "/flag.json", "", "/env.json", "/flag.json"},
+	}
+	for _, tt := range tests {
+		t.Setenv("SSM_CONFIG", tt.env)
+		got, err := ResolveConfigFile(tt.configFlag, tt.profileFlag)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: ResolveConfigFile() = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := ResolveConfigFile("/flag.json", "home"); err == nil {
+		t.Error("ResolveConfigFile() expected an error for --config with --profile")
+	}
+	if _, err := ResolveConfigFile("", "../escape"); err == nil {
+		t.Error("ResolveConfigFile() expected an error for an invalid profile name")
+	}
+}
+
+func TestListProfiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("SSM_HOME", home)
+
+	for _, name := range []string{"work", "lab"} {
+		if err := NewFileStore(GetProfilePath(name)).Put(&SSHConfig{Host: name, Username: "root", Port: "22"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 备份、锁文件等不是配置
+	if err := os.WriteFile(filepath.Join(home, "profiles", "notes.txt"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{DefaultProfile, "lab", "work"}; !reflect.DeepEqual(profiles, want) {
+		t.Errorf("ListProfiles() = %v, want %v", profiles, want)
+	}
+
+	if err := SetActiveProfile("lab"); err != nil {
+		t.Fatal(err)
+	}
+	if active := GetActiveProfile(); active != "lab" {
+		t.Errorf("GetActiveProfile() = %q, want lab", active)
+	}
+	if err := SetActiveProfile(DefaultProfile); err != nil {
+		t.Fatal(err)
+	}
+	if active := GetActiveProfile(); active != DefaultProfile {
+		t.Errorf("GetActiveProfile() = %q, want %s", active, DefaultProfile)
+	}
+}
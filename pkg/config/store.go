@@ -0,0 +1,254 @@
+// pkg/config/store.go
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+// ErrReadOnly 只读的配置存储不能修改
+var ErrReadOnly = errors.New("config store is read-only")
+
+// Store 连接配置存储
+type Store interface {
+	// Get 按配置键（user@host:port）获取配置
+	Get(key string) (*SSHConfig, bool)
+	// Put 保存配置，别名已被其他配置使用时返回错误
+	Put(config *SSHConfig) error
+	// Delete 删除配置，配置不存在时返回错误
+	Delete(key string) error
+	// Move 将 key 的配置替换为 config 并移到新的配置键，一次完成删除和写入；新键已存在时返回错误。
+	// 其他配置的跳板机链按地址引用旧配置时改为新地址，返回这些配置的键
+	Move(key string, config *SSHConfig) ([]string, error)
+	// List 列出所有配置，按最后使用时间倒序
+	List() ([]SSHConfig, error)
+	// Watch 监听配置变化，ctx 结束后关闭返回的通道
+	Watch(ctx context.Context) (<-chan Event, error)
+}
+
+// EventType 配置变化类型
+type EventType int
+
+const (
+	EventPut    EventType = iota // 新增或修改
+	EventDelete                  // 删除
+)
+
+// Event 配置变化
+type Event struct {
+	Type   EventType
+	Key    string
+	Config SSHConfig // 删除时为删除前的配置
+}
+
+// put 写入配置，别名已被其他配置使用时返回错误；从模式继承的字段不写入
+func (s *ConfigStore) put(config SSHConfig) error {
+	config = config.withoutInherited()
+	key := config.GetKey()
+	if config.Alias != "" {
+		if owner, exists := s.FindAlias(config.Alias); exists && owner != key {
+			return fmt.Errorf("alias %q is already used by %s", config.Alias, owner)
+		}
+	}
+	s.Items[key] = config
+	return nil
+}
+
+// remove 删除配置
+func (s *ConfigStore) remove(key string) error {
+	if _, exists := s.Items[key]; !exists {
+		return fmt.Errorf("connection config not found: %s", key)
+	}
+	delete(s.Items, key)
+	return nil
+}
+
+// move 将配置移到新键，并把按地址引用旧配置的跳板机改为新地址，返回修改了跳板机的配置键
+func (s *ConfigStore) move(key string, config SSHConfig) ([]string, error) {
+	old, exists := s.Items[key]
+	if !exists {
+		return nil, fmt.Errorf("connection config not found: %s", key)
+	}
+	newKey := config.GetKey()
+	if newKey == key {
+		return nil, s.put(config)
+	}
+	if _, exists := s.Items[newKey]; exists {
+		return nil, fmt.Errorf("%s already exists", newKey)
+	}
+
+	// 按移动前的配置解析跳板机地址（与连接时一致：别名优先，未指定的用户和端口使用模式的设置）
+	view := NewMemoryStore(s.list()...)
+
+	// 先删除旧配置，新配置才能使用同一个别名
+	delete(s.Items, key)
+	if err := s.put(config); err != nil {
+		s.Items[key] = old
+		return nil, err
+	}
+
+	var updated []string
+	for itemKey, item := range s.Items {
+		if proxyJump, changed := replaceHop(view, item.ProxyJump, key, newKey); changed {
+			item.ProxyJump = proxyJump
+			s.Items[itemKey] = item
+			updated = append(updated, itemKey)
+		}
+	}
+	sort.Strings(updated)
+	return updated, nil
+}
+
+// replaceHop 将跳板机链中按地址指向 oldKey 的跳板机改为 newKey，别名引用不变
+func replaceHop(s Store, proxyJump, oldKey, newKey string) (string, bool) {
+	if proxyJump == "" {
+		return proxyJump, false
+	}
+
+	hops := strings.Split(proxyJump, ",")
+	changed := false
+	for i, hop := range hops {
+		hop = strings.TrimSpace(hop)
+		if _, aliased := GetByAlias(s, hop); aliased {
+			continue
+		}
+		username, hostname, port, _ := ResolveAddress(s, hop)
+		if utils.GetConfigKey(username, hostname, port) == oldKey {
+			hops[i] = newKey
+			changed = true
+		}
+	}
+	return strings.Join(hops, ","), changed
+}
+
+// list 复制所有配置，按最后使用时间倒序
+func (s *ConfigStore) list() []SSHConfig {
+	configs := make([]SSHConfig, 0, len(s.Items))
+	for _, config := range s.Items {
+		configs = append(configs, config)
+	}
+
+	sort.Slice(configs, func(i, j int) bool {
+		if configs[i].LastUsed != configs[j].LastUsed {
+			return configs[i].LastUsed > configs[j].LastUsed
+		}
+		return configs[i].GetKey() < configs[j].GetKey()
+	})
+	return configs
+}
+
+// Snapshot 读取存储中的全部配置
+func Snapshot(s Store) (*ConfigStore, error) {
+	configs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &ConfigStore{Version: CurrentVersion, Items: make(map[string]SSHConfig, len(configs))}
+	for _, config := range configs {
+		snapshot.Items[config.GetKey()] = config
+	}
+	return snapshot, nil
+}
+
+// GetByAlias 按别名获取配置
+func GetByAlias(s Store, alias string) (*SSHConfig, bool) {
+	configs, err := s.List()
+	if err != nil {
+		return nil, false
+	}
+
+	for _, config := range configs {
+		if config.Alias == alias {
+			return &config, true
+		}
+	}
+	return nil, false
+}
+
+// SetAlias 设置配置的别名，配置不存在时创建，别名已被其他配置使用时返回错误
+func SetAlias(s Store, config *SSHConfig, alias string) error {
+	if err := ValidateAlias(alias); err != nil {
+		return err
+	}
+
+	if existing, exists := s.Get(config.GetKey()); exists {
+		*config = *existing
+	}
+	config.Alias = alias
+	return s.Put(config)
+}
+
+// RemoveAlias 删除别名，返回别名所属的配置键
+func RemoveAlias(s Store, alias string) (string, error) {
+	config, exists := GetByAlias(s, alias)
+	if !exists {
+		return "", fmt.Errorf("alias not found: %s", alias)
+	}
+
+	config.Alias = ""
+	return config.GetKey(), s.Put(config)
+}
+
+// watchChanges 等待上一次快照返回的 next 通道有信号后重新获取快照，与上一次快照比较并发送变化。
+// snapshot 出错时也要返回 next
+func watchChanges(ctx context.Context, snapshot func() (map[string]SSHConfig, <-chan struct{}, error)) (<-chan Event, error) {
+	previous, next, err := snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-next:
+			}
+
+			current, n, err := snapshot()
+			if err != nil {
+				// 读取失败（如写入中途）时等待下一次
+				next = n
+				continue
+			}
+			for _, event := range diffItems(previous, current) {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			previous, next = current, n
+		}
+	}()
+	return events, nil
+}
+
+// diffItems 比较两次快照，按配置键排序返回变化
+func diffItems(previous, current map[string]SSHConfig) []Event {
+	var events []Event
+	for key, config := range current {
+		if old, exists := previous[key]; !exists || !reflect.DeepEqual(old, config) {
+			events = append(events, Event{Type: EventPut, Key: key, Config: config})
+		}
+	}
+	for key, config := range previous {
+		if _, exists := current[key]; !exists {
+			events = append(events, Event{Type: EventDelete, Key: key, Config: config})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Key < events[j].Key
+	})
+	return events
+}
@@ -0,0 +1,201 @@
+// pkg/config/store_test.go
+package config
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStoreAliasUniqueness(t *testing.T) {
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   newTestFileStore(t),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			web := &SSHConfig{Host: "web", Username: "root", Port: "22"}
+			if err := SetAlias(store, web, "prod"); err != nil {
+				t.Fatalf("SetAlias() error = %v", err)
+			}
+			if got, exists := GetByAlias(store, "prod"); !exists || got.GetKey() != web.GetKey() {
+				t.Errorf("GetByAlias(prod) = %v, %v", got, exists)
+			}
+
+			db := &SSHConfig{Host: "db", Username: "root", Port: "22", Alias: "prod"}
+			if err := store.Put(db); err == nil {
+				t.Error("Put() expected an error for an alias used by another config")
+			}
+
+			key, err := RemoveAlias(store, "prod")
+			if err != nil || key != web.GetKey() {
+				t.Errorf("RemoveAlias() = %q, %v", key, err)
+			}
+			if err := store.Put(db); err != nil {
+				t.Errorf("Put() error = %v after the alias was removed", err)
+			}
+
+			if err := store.Delete(web.GetKey()); err != nil {
+				t.Errorf("Delete() error = %v", err)
+			}
+			if err := store.Delete(web.GetKey()); err == nil {
+				t.Error("Delete() expected an error for a missing config")
+			}
+			if configs, _ := store.List(); len(configs) != 1 {
+				t.Errorf("List() has %d items, want 1", len(configs))
+			}
+		})
+	}
+}
+
+func TestStoreMove(t *testing.T) {
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   newTestFileStore(t),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			for _, config := range []SSHConfig{
+				{Host: "10.0.0.1", Username: "deploy", Port: "22", Alias: "bastion", Notes: "jump"},
+				{Host: "web", Username: "deploy", Port: "22", ProxyJump: "deploy@10.0.0.1:22"},
+				{Host: "db", Username: "deploy", Port: "22", ProxyJump: "outer, deploy@10.0.0.1"},
+				{Host: "cache", Username: "deploy", Port: "22", ProxyJump: "bastion"},
+				{Host: "*.corp", ProxyJump: "deploy@10.0.0.1:22"},
+				{Host: "10.0.0.9", Username: "deploy", Port: "22"},
+			} {
+				if err := store.Put(&config); err != nil {
+					t.Fatalf("Put() error = %v", err)
+				}
+			}
+
+			moved, _ := store.Get("deploy@10.0.0.1:22")
+			moved.Host = "10.0.0.2"
+			updated, err := store.Move("deploy@10.0.0.1:22", moved)
+			if err != nil {
+				t.Fatalf("Move() error = %v", err)
+			}
+			if !reflect.DeepEqual(updated, []string{"*.corp", "deploy@db:22", "deploy@web:22"}) {
+				t.Errorf("Move() updated %v, want the configs jumping through the old address", updated)
+			}
+
+			if _, exists := store.Get("deploy@10.0.0.1:22"); exists {
+				t.Error("old key still exists after Move()")
+			}
+			if got, exists := GetByAlias(store, "bastion"); !exists || got.GetKey() != "deploy@10.0.0.2:22" || got.Notes != "jump" {
+				t.Errorf("moved config = %+v, want the alias and notes kept", got)
+			}
+			for key, want := range map[string]string{
+				"deploy@web:22":   "deploy@10.0.0.2:22",
+				"deploy@db:22":    "outer,deploy@10.0.0.2:22",
+				"deploy@cache:22": "bastion",
+				"*.corp":          "deploy@10.0.0.2:22",
+			} {
+				if got, _ := store.Get(key); got.ProxyJump != want {
+					t.Errorf("%s proxy_jump = %q, want %q", key, got.ProxyJump, want)
+				}
+			}
+
+			// 新键已存在或旧配置不存在时不修改
+			moved.Host = "10.0.0.9"
+			if _, err := store.Move("deploy@10.0.0.2:22", moved); err == nil {
+				t.Error("Move() onto an existing key expected an error")
+			}
+			if _, exists := store.Get("deploy@10.0.0.2:22"); !exists {
+				t.Error("config was removed by a failed Move()")
+			}
+			if _, err := store.Move("deploy@missing:22", moved); err == nil {
+				t.Error("Move() of a missing config expected an error")
+			}
+		})
+	}
+}
+
+func TestLayeredStore(t *testing.T) {
+	user := NewMemoryStore(
+		SSHConfig{Host: "web", Username: "root", Port: "22", PrivateKey: "/user/key", Alias: "web"},
+	)
+	system := NewMemoryStore(
+		SSHConfig{Host: "web", Username: "root", Port: "22", PrivateKey: "/system/key"},
+		SSHConfig{Host: "db", Username: "root", Port: "22", Alias: "web"},
+	)
+	layered := NewLayeredStore(user, system)
+
+	web, exists := layered.Get("root@web:22")
+	if !exists || web.PrivateKey != "/user/key" {
+		t.Errorf("Get() = %v, want the config from the first layer", web)
+	}
+
+	configs, err := layered.List()
+	if err != nil || len(configs) != 2 {
+		t.Fatalf("List() = %v, %v, want 2 configs", configs, err)
+	}
+	if db, _ := layered.Get("root@db:22"); db.Alias != "" {
+		t.Errorf("alias %q of a lower layer shadows the first layer", db.Alias)
+	}
+
+	if err := layered.Put(web); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Put() error = %v, want ErrReadOnly", err)
+	}
+	if err := layered.Delete(web.GetKey()); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Delete() error = %v, want ErrReadOnly", err)
+	}
+}
+
+// nextEvent 等待下一个配置变化
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	interval := watchInterval
+	watchInterval = 10 * time.Millisecond
+	t.Cleanup(func() { watchInterval = interval })
+
+	memory := NewMemoryStore()
+	file := newTestFileStore(t)
+	stores := map[string]Store{
+		"memory":  memory,
+		"file":    file,
+		"layered": NewLayeredStore(memory),
+	}
+	writers := map[string]Store{"memory": memory, "file": file, "layered": memory}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			events, err := store.Watch(ctx)
+			if err != nil {
+				t.Fatalf("Watch() error = %v", err)
+			}
+
+			writer := writers[name]
+			cfg := &SSHConfig{Host: name, Username: "root", Port: "22"}
+			if err := writer.Put(cfg); err != nil {
+				t.Fatal(err)
+			}
+			if event := nextEvent(t, events); event.Type != EventPut || event.Key != cfg.GetKey() {
+				t.Errorf("event = %+v, want put %s", event, cfg.GetKey())
+			}
+
+			if err := writer.Delete(cfg.GetKey()); err != nil {
+				t.Fatal(err)
+			}
+			if event := nextEvent(t, events); event.Type != EventDelete || event.Config.Host != name {
+				t.Errorf("event = %+v, want delete %s", event, cfg.GetKey())
+			}
+
+			cancel()
+			for range events {
+			}
+		})
+	}
+}
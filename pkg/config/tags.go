@@ -0,0 +1,103 @@
+// pkg/config/tags.go
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ValidateTag 检查标签格式
+func ValidateTag(tag string) error {
+	if !aliasPattern.MatchString(tag) {
+		return fmt.Errorf("invalid tag %q: use letters, digits, '.', '_' and '-' only", tag)
+	}
+	return nil
+}
+
+// ValidateGroup 检查分组名称格式
+func ValidateGroup(group string) error {
+	if !aliasPattern.MatchString(group) {
+		return fmt.Errorf("invalid group %q: use letters, digits, '.', '_' and '-' only", group)
+	}
+	return nil
+}
+
+// HasTag 是否带有该标签
+func (c *SSHConfig) HasTag(tag string) bool {
+	for _, t := range c.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTags 是否带有所有指定的标签，未指定标签时总是匹配
+func (c *SSHConfig) MatchTags(tags []string) bool {
+	for _, tag := range tags {
+		if !c.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// AddTags 添加标签，返回新增的标签
+func (c *SSHConfig) AddTags(tags ...string) []string {
+	var added []string
+	merged := append([]string(nil), c.Tags...)
+	for _, tag := range tags {
+		if !containsString(merged, tag) {
+			merged = append(merged, tag)
+			added = append(added, tag)
+		}
+	}
+	sort.Strings(merged)
+	c.Tags = merged
+	return added
+}
+
+// RemoveTags 删除标签，返回实际删除的标签
+func (c *SSHConfig) RemoveTags(tags ...string) []string {
+	var removed, kept []string
+	for _, t := range c.Tags {
+		if containsString(tags, t) {
+			removed = append(removed, t)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	c.Tags = kept
+	return removed
+}
+
+// clone 复制配置，不与原配置共用标签切片
+func (c SSHConfig) clone() SSHConfig {
+	c.Tags = append([]string(nil), c.Tags...)
+	return c
+}
+
+// FilterByTags 筛选带有所有指定标签的配置
+func FilterByTags(configs []SSHConfig, tags []string) []SSHConfig {
+	if len(tags) == 0 {
+		return configs
+	}
+
+	var filtered []SSHConfig
+	for _, config := range configs {
+		if config.MatchTags(tags) {
+			filtered = append(filtered, config)
+		}
+	}
+	return filtered
+}
+
+// containsString 切片中是否包含该字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
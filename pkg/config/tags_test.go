@@ -0,0 +1,36 @@
+// pkg/config/tags_test.go
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	cfg := SSHConfig{Host: "web", Username: "root", Port: "22"}
+
+	if added := cfg.AddTags("web", "prod", "web"); !reflect.DeepEqual(added, []string{"web", "prod"}) {
+		t.Errorf("AddTags() = %v, want [web prod]", added)
+	}
+	if !reflect.DeepEqual(cfg.Tags, []string{"prod", "web"}) {
+		t.Errorf("Tags = %v, want sorted [prod web]", cfg.Tags)
+	}
+	if !cfg.MatchTags([]string{"web", "prod"}) || cfg.MatchTags([]string{"web", "db"}) {
+		t.Error("MatchTags() should require all tags")
+	}
+
+	// 修改副本的标签不影响原配置
+	copied := cfg
+	copied.AddTags("db")
+	copied.RemoveTags("prod")
+	if !reflect.DeepEqual(cfg.Tags, []string{"prod", "web"}) {
+		t.Errorf("changing a copy modified the original tags: %v", cfg.Tags)
+	}
+
+	if removed := cfg.RemoveTags("web", "missing"); !reflect.DeepEqual(removed, []string{"web"}) {
+		t.Errorf("RemoveTags() = %v, want [web]", removed)
+	}
+	if removed := cfg.RemoveTags("prod"); len(removed) != 1 || cfg.Tags != nil {
+		t.Errorf("Tags = %#v after removing all tags, want nil", cfg.Tags)
+	}
+}
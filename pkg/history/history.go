@@ -0,0 +1,429 @@
+// pkg/history/history.go
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+// 记录类型
+const (
+	KindSSH  = "ssh"  // 交互式会话
+	KindExec = "exec" // ssm exec
+	KindCp   = "cp"   // ssm cp
+)
+
+// 默认保留设置
+const (
+	DefaultMaxAgeDays = 180
+	DefaultMaxEntries = 10000
+)
+
+// pruneSlack 写入时条数超出保留条数的 1/pruneSlack、或最早的记录超出保留期限一天后才清理，
+// 避免每次写入都重写文件；尚未清理的旧记录读取时已被忽略
+const pruneSlack = 10
+
+// Entry 一次连接的记录
+type Entry struct {
+	Kind       string    `json:"kind"`
+	Host       string    `json:"host"` // 配置键 user@host:port
+	Alias      string    `json:"alias,omitempty"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Duration   float64   `json:"duration"`    // 秒
+	ExitStatus int       `json:"exit_status"` // 远程命令的退出码，连接失败时为 -1
+	Error      string    `json:"error,omitempty"`
+	ProxyJump  string    `json:"proxy_jump,omitempty"`
+	Forwards   []string  `json:"forwards,omitempty"` // 端口转发，如 "L 8080:localhost:80"
+	Command    string    `json:"command,omitempty"`  // ssm exec 执行的命令
+	Transfer   *Transfer `json:"transfer,omitempty"` // ssm cp 的传输
+}
+
+// Transfer 文件传输记录
+type Transfer struct {
+	Direction   string `json:"direction"` // upload 或 download
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Files       int    `json:"files"`
+	Bytes       int64  `json:"bytes"`
+}
+
+// Finish 记录结束时间、时长和结果
+func (e *Entry) Finish(exitStatus int, err error) {
+	e.End = time.Now()
+	e.Duration = e.End.Sub(e.Start).Seconds()
+	e.ExitStatus = exitStatus
+	if err != nil {
+		e.Error = err.Error()
+	}
+}
+
+// Failed 连接失败或远程命令退出码非零
+func (e *Entry) Failed() bool {
+	return e.Error != "" || e.ExitStatus != 0
+}
+
+// Retention 保留设置，0 表示不限制
+type Retention struct {
+	MaxAgeDays int `json:"max_age_days"`
+	MaxEntries int `json:"max_entries"`
+}
+
+// keep 返回按保留设置应保留的记录（按时间顺序）
+func (r Retention) keep(entries []Entry, now time.Time) []Entry {
+	kept := entries
+	if r.MaxAgeDays > 0 {
+		cutoff := now.AddDate(0, 0, -r.MaxAgeDays)
+		kept = kept[:0:0]
+		for _, entry := range entries {
+			if !entry.Start.Before(cutoff) {
+				kept = append(kept, entry)
+			}
+		}
+	}
+	if r.MaxEntries > 0 && len(kept) > r.MaxEntries {
+		kept = kept[len(kept)-r.MaxEntries:]
+	}
+	return kept
+}
+
+// Filter 查询条件，零值表示不限制
+type Filter struct {
+	Host   string    // 配置键、别名、主机名或主机名通配符（如 *.corp）
+	Kind   string    // ssh、exec 或 cp
+	Since  time.Time // 开始时间不早于
+	Until  time.Time // 开始时间早于
+	Failed bool      // 只显示失败的记录
+}
+
+// Match 记录是否满足查询条件
+func (f *Filter) Match(e *Entry) bool {
+	if f.Kind != "" && e.Kind != f.Kind {
+		return false
+	}
+	if !f.Since.IsZero() && e.Start.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Start.Before(f.Until) {
+		return false
+	}
+	if f.Failed && !e.Failed() {
+		return false
+	}
+	if f.Host != "" && f.Host != e.Host && f.Host != e.Alias {
+		_, hostname, _ := strings.Cut(e.Host, "@")
+		if i := strings.LastIndex(hostname, ":"); i >= 0 {
+			hostname = hostname[:i]
+		}
+		if f.Host != hostname && !(config.IsPattern(f.Host) && config.MatchPattern(f.Host, hostname)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Log 追加写入的连接历史（每行一条 JSON 记录）
+type Log struct {
+	path string
+}
+
+// GetHistoryPath 获取配置目录下的历史文件路径
+func GetHistoryPath(configDir string) string {
+	return filepath.Join(configDir, "history.log")
+}
+
+// getRetentionPath 获取保留设置文件路径
+func getRetentionPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "_retention.json"
+}
+
+// getCountPath 获取记录条数文件路径，写入时据此判断是否需要清理，不必读取整个历史文件
+func getCountPath(path string) string {
+	return path + ".count"
+}
+
+// Open 打开历史文件，文件不存在时在第一次写入时创建
+func Open(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path 历史文件路径
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append 追加一条记录，超出保留设置较多时清理旧记录（见 pruneSlack）
+func (l *Log) Append(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %v", err)
+	}
+
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	count, err := l.count()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %v", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+	count++
+
+	retention, err := l.Retention()
+	if err != nil {
+		return err
+	}
+	if !l.exceeds(retention, count, time.Now()) {
+		return l.setCount(count)
+	}
+	_, err = l.prune(retention, time.Now())
+	return err
+}
+
+// Read 读取满足条件且在保留设置内的记录，按时间顺序；无法解析的行被跳过
+func (l *Log) Read(filter Filter) ([]Entry, error) {
+	entries, err := l.readAll()
+	if err != nil {
+		return nil, err
+	}
+	retention, err := l.Retention()
+	if err != nil {
+		return nil, err
+	}
+	entries = retention.keep(entries, time.Now())
+
+	var matched []Entry
+	for i := range entries {
+		if filter.Match(&entries[i]) {
+			matched = append(matched, entries[i])
+		}
+	}
+	return matched, nil
+}
+
+// Prune 按保留设置删除旧记录，返回删除的记录数
+func (l *Log) Prune(retention Retention) (int, error) {
+	unlock, err := l.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	return l.prune(retention, time.Now())
+}
+
+// Clear 删除所有记录
+func (l *Log) Clear() error {
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear history: %v", err)
+	}
+	if err := os.Remove(getCountPath(l.path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear history: %v", err)
+	}
+	return nil
+}
+
+// Retention 读取保留设置，没有设置时使用默认值
+func (l *Log) Retention() (Retention, error) {
+	retention := Retention{MaxAgeDays: DefaultMaxAgeDays, MaxEntries: DefaultMaxEntries}
+	data, err := os.ReadFile(getRetentionPath(l.path))
+	if os.IsNotExist(err) {
+		return retention, nil
+	}
+	if err != nil {
+		return retention, fmt.Errorf("failed to read history retention: %v", err)
+	}
+	if err := json.Unmarshal(data, &retention); err != nil {
+		return retention, fmt.Errorf("failed to parse history retention: %v", err)
+	}
+	return retention, nil
+}
+
+// SetRetention 保存保留设置
+func (l *Log) SetRetention(retention Retention) error {
+	if retention.MaxAgeDays < 0 || retention.MaxEntries < 0 {
+		return fmt.Errorf("history retention must not be negative")
+	}
+
+	data, err := json.MarshalIndent(retention, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history retention: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %v", err)
+	}
+	if err := os.WriteFile(getRetentionPath(l.path), data, 0600); err != nil {
+		return fmt.Errorf("failed to write history retention: %v", err)
+	}
+	return nil
+}
+
+// exceeds 写入后是否需要清理：条数或最早记录的时间超出保留设置及其余量
+func (l *Log) exceeds(retention Retention, count int, now time.Time) bool {
+	if retention.MaxEntries > 0 && count > retention.MaxEntries+retention.MaxEntries/pruneSlack {
+		return true
+	}
+	if retention.MaxAgeDays > 0 {
+		oldest, ok := l.first()
+		return ok && oldest.Start.Before(now.AddDate(0, 0, -retention.MaxAgeDays-1))
+	}
+	return false
+}
+
+// prune 删除超过保留期限和条数的记录，有删除时重写文件（调用方需持有锁）
+func (l *Log) prune(retention Retention, now time.Time) (int, error) {
+	entries, err := l.readAll()
+	if err != nil {
+		return 0, err
+	}
+
+	kept := retention.keep(entries, now)
+	removed := len(entries) - len(kept)
+	if removed == 0 {
+		return 0, l.setCount(len(entries))
+	}
+
+	var buf bytes.Buffer
+	for i := range kept {
+		data, err := json.Marshal(&kept[i])
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal history entry: %v", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if err := utils.WriteFileAtomic(l.path, buf.Bytes(), 0600); err != nil {
+		return 0, fmt.Errorf("failed to write history: %v", err)
+	}
+	return removed, l.setCount(len(kept))
+}
+
+// count 获取历史文件中的记录条数，条数文件不存在或无法解析时读取历史文件计数（调用方需持有锁）
+func (l *Log) count() (int, error) {
+	if data, err := os.ReadFile(getCountPath(l.path)); err == nil {
+		if count, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && count >= 0 {
+			return count, nil
+		}
+	}
+
+	entries, err := l.readAll()
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// setCount 保存记录条数（调用方需持有锁）
+func (l *Log) setCount(count int) error {
+	if err := os.WriteFile(getCountPath(l.path), []byte(strconv.Itoa(count)+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write history count: %v", err)
+	}
+	return nil
+}
+
+// first 读取最早的一条记录
+func (l *Log) first() (Entry, bool) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return Entry{}, false
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return Entry{}, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// readAll 读取所有记录
+func (l *Log) readAll() ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %v", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %v", err)
+	}
+	return entries, nil
+}
+
+// lock 获取历史文件的排他锁（各 ssm 进程之间生效），返回释放函数
+func (l *Log) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %v", err)
+	}
+
+	f, err := os.OpenFile(l.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history lock: %v", err)
+	}
+
+	for {
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX)
+		if err != unix.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock history: %v", err)
+	}
+
+	return func() {
+		unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		f.Close()
+	}, nil
+}
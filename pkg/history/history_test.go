@@ -0,0 +1,148 @@
+// pkg/history/history_test.go
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	log := Open(filepath.Join(t.TempDir(), "history.log"))
+	start := time.Now().Add(-time.Hour)
+
+	entries := []Entry{
+		{Kind: KindSSH, Host: "root@web1.corp:22", Alias: "web1", Start: start, Forwards: []string{"L 8080:localhost:80"}},
+		{Kind: KindExec, Host: "root@10.0.0.1:22", Start: start.Add(time.Minute), ExitStatus: 2, Command: "false"},
+		{Kind: KindCp, Host: "root@web2.corp:22", Start: start.Add(2 * time.Minute),
+			Transfer: &Transfer{Direction: "upload", Source: "a", Destination: "web2.corp:/tmp", Files: 1, Bytes: 42}},
+	}
+	for i := range entries {
+		if err := log.Append(&entries[i]); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"all", Filter{}, 3},
+		{"alias", Filter{Host: "web1"}, 1},
+		{"key", Filter{Host: "root@10.0.0.1:22"}, 1},
+		{"hostname", Filter{Host: "10.0.0.1"}, 1},
+		{"glob", Filter{Host: "*.corp"}, 2},
+		{"kind", Filter{Kind: KindCp}, 1},
+		{"failed", Filter{Failed: true}, 1},
+		{"since", Filter{Since: start.Add(30 * time.Second)}, 2},
+		{"until", Filter{Until: start.Add(90 * time.Second)}, 2},
+	}
+	for _, tt := range tests {
+		got, err := log.Read(tt.filter)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: Read() returned %d entries, want %d", tt.name, len(got), tt.want)
+		}
+	}
+
+	got, _ := log.Read(Filter{Kind: KindCp})
+	if len(got) != 1 || got[0].Transfer == nil || got[0].Transfer.Bytes != 42 {
+		t.Errorf("cp entry was not read back: %+v", got)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	log := Open(filepath.Join(t.TempDir(), "history.log"))
+
+	retention, err := log.Retention()
+	if err != nil || retention.MaxAgeDays != DefaultMaxAgeDays || retention.MaxEntries != DefaultMaxEntries {
+		t.Fatalf("Retention() = %+v, %v, want the defaults", retention, err)
+	}
+	if err := log.SetRetention(Retention{MaxAgeDays: 30, MaxEntries: 2}); err != nil {
+		t.Fatalf("SetRetention() error = %v", err)
+	}
+
+	now := time.Now()
+	for _, start := range []time.Time{now.AddDate(0, 0, -40), now.Add(-3 * time.Hour), now.Add(-2 * time.Hour), now.Add(-time.Hour)} {
+		if err := log.Append(&Entry{Kind: KindSSH, Host: "root@web:22", Start: start}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	entries, _ := log.Read(Filter{})
+	if len(entries) != 2 || !entries[0].Start.Equal(now.Add(-2*time.Hour)) {
+		t.Errorf("kept %d entries, want the 2 newest", len(entries))
+	}
+
+	removed, err := log.Prune(Retention{MaxEntries: 1})
+	if err != nil || removed != 1 {
+		t.Errorf("Prune() = %d, %v, want 1 removed", removed, err)
+	}
+}
+
+func TestAppendPrunesBeyondSlack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.log")
+	log := Open(path)
+	if err := log.SetRetention(Retention{MaxAgeDays: 30, MaxEntries: 10}); err != nil {
+		t.Fatalf("SetRetention() error = %v", err)
+	}
+	lines := func() int {
+		data, _ := os.ReadFile(path)
+		return bytes.Count(data, []byte("\n"))
+	}
+
+	// 超出保留条数不多时不重写文件，读取时只返回保留的记录
+	now := time.Now()
+	for i := 0; i < 11; i++ {
+		if err := log.Append(&Entry{Kind: KindSSH, Host: "root@web:22", Start: now.Add(time.Duration(i-20) * time.Minute)}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if got := lines(); got != 11 {
+		t.Errorf("history has %d lines, want 11 before the slack is used up", got)
+	}
+	if entries, _ := log.Read(Filter{}); len(entries) != 10 {
+		t.Errorf("Read() returned %d entries, want 10", len(entries))
+	}
+
+	if err := log.Append(&Entry{Kind: KindSSH, Host: "root@web:22", Start: now}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if got := lines(); got != 10 {
+		t.Errorf("history has %d lines, want 10 after pruning", got)
+	}
+
+	// 最早的记录刚超出保留期限时不清理，超出一天后清理
+	if err := log.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	for _, start := range []time.Time{now.AddDate(0, 0, -30).Add(-time.Hour), now} {
+		if err := log.Append(&Entry{Kind: KindSSH, Host: "root@web:22", Start: start}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if got := lines(); got != 2 {
+		t.Errorf("history has %d lines, want 2 within a day of the age limit", got)
+	}
+	if entries, _ := log.Read(Filter{}); len(entries) != 1 {
+		t.Errorf("Read() returned %d entries, want the expired one hidden", len(entries))
+	}
+
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(getCountPath(path))
+	for _, start := range []time.Time{now.AddDate(0, 0, -32), now} {
+		if err := log.Append(&Entry{Kind: KindSSH, Host: "root@web:22", Start: start}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if got := lines(); got != 1 {
+		t.Errorf("history has %d lines, want the entry expired for over a day removed", got)
+	}
+}
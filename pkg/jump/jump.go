@@ -0,0 +1,139 @@
+// pkg/jump/jump.go
+package jump
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/wuxs/ssm/pkg/auth"
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+// ParseChain 拆分逗号分隔的跳板机链（a,b,c 依次连接）
+func ParseChain(proxyJump string) []string {
+	var hops []string
+	for _, hop := range strings.Split(proxyJump, ",") {
+		if hop = strings.TrimSpace(hop); hop != "" {
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// LoadHopConfig 获取跳板机配置，依次查找别名、已保存的配置，都没有时新建；
+// 未设置的字段使用匹配模式的设置
+func LoadHopConfig(store config.Store, hop string) *config.SSHConfig {
+	hopConfig, exists := config.GetByAlias(store, hop)
+	if !exists {
+		username, hostname, port, _ := config.ResolveAddress(store, hop)
+		if hopConfig, exists = store.Get(utils.GetConfigKey(username, hostname, port)); !exists {
+			hopConfig = &config.SSHConfig{
+				Host:     hostname,
+				Username: username,
+				Port:     port,
+			}
+		}
+	}
+	config.ApplyPatterns(store, hopConfig)
+	return hopConfig
+}
+
+// Dial 连接目标主机，配置了跳板机链时逐跳连接，每一跳使用自己保存的认证配置。
+// 任一跳失败时关闭已建立的连接；目标连接关闭后自动关闭所有中间连接。
+// 跳板机配置从 store 读取，使用 authManager 认证，连接成功后写回；连接进度和警告写入 out
+func Dial(store config.Store, authManager *auth.AuthManager, cfg *config.SSHConfig, clientConfig *auth.ClientConfig, out io.Writer) (*ssh.Client, error) {
+	targetAddr := net.JoinHostPort(cfg.Host, cfg.Port)
+	hops := ParseChain(cfg.ProxyJump)
+
+	// 如果没有跳板机，直接连接
+	if len(hops) == 0 {
+		fmt.Fprintf(out, "Connecting to %s...\n", targetAddr)
+		client, err := ssh.Dial("tcp", targetAddr, clientConfig.ClientConfig)
+		return client, clientConfig.ExplainAuthError(err)
+	}
+
+	var clients []*ssh.Client
+	closeAll := func() {
+		for i := len(clients) - 1; i >= 0; i-- {
+			clients[i].Close()
+		}
+	}
+
+	for _, hop := range hops {
+		hopConfig := LoadHopConfig(store, hop)
+		hopClient, err := dialHop(authManager, clients, hopConfig, out)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		clients = append(clients, hopClient)
+		saveHopConfig(store, authManager, hopConfig, out)
+	}
+
+	// 通过最后一个跳板机连接到目标服务器
+	last := clients[len(clients)-1]
+	fmt.Fprintf(out, "Connecting to target host %s through jump host...\n", targetAddr)
+	client, err := dialThrough(last, targetAddr, clientConfig)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	go func() {
+		client.Wait()
+		closeAll()
+	}()
+	return client, nil
+}
+
+// dialHop 连接一个跳板机，前面已有跳板机时通过最后一个跳板机连接
+func dialHop(authManager *auth.AuthManager, clients []*ssh.Client, hopConfig *config.SSHConfig, out io.Writer) (*ssh.Client, error) {
+	hopClientConfig, err := authManager.CreateClientConfig(hopConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jump host SSH config: %v", err)
+	}
+
+	hopAddr := net.JoinHostPort(hopConfig.Host, hopConfig.Port)
+	if len(clients) == 0 {
+		fmt.Fprintf(out, "Connecting to jump host %s...\n", hopAddr)
+		client, err := ssh.Dial("tcp", hopAddr, hopClientConfig.ClientConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to jump host %s: %v", hopAddr, hopClientConfig.ExplainAuthError(err))
+		}
+		return client, nil
+	}
+
+	fmt.Fprintf(out, "Connecting to jump host %s through previous jump host...\n", hopAddr)
+	client, err := dialThrough(clients[len(clients)-1], hopAddr, hopClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to jump host %s: %v", hopAddr, err)
+	}
+	return client, nil
+}
+
+// dialThrough 通过已建立的连接转发 TCP 并建立新的 SSH 连接
+func dialThrough(via *ssh.Client, addr string, clientConfig *auth.ClientConfig) (*ssh.Client, error) {
+	conn, err := via.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s through jump host: %v", addr, err)
+	}
+
+	ncc, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig.ClientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to establish SSH connection: %v", clientConfig.ExplainAuthError(err))
+	}
+	return ssh.NewClient(ncc, chans, reqs), nil
+}
+
+// saveHopConfig 保存连接成功的跳板机配置
+func saveHopConfig(store config.Store, authManager *auth.AuthManager, hopConfig *config.SSHConfig, out io.Writer) {
+	if err := authManager.SaveLogin(store, hopConfig); err != nil {
+		fmt.Fprintf(out, "Warning: Jump host %s: %v\n", hopConfig.GetKey(), err)
+	}
+}
@@ -0,0 +1,251 @@
+// pkg/jump/jump_test.go
+package jump
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/wuxs/ssm/pkg/auth"
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+func TestParseChain(t *testing.T) {
+	tests := []struct {
+		proxyJump string
+		want      []string
+	}{
+		{"", nil},
+		{"bastion", []string{"bastion"}},
+		{"a@b:22, c ,,d", []string{"a@b:22", "c", "d"}},
+	}
+	for _, tt := range tests {
+		if got := ParseChain(tt.proxyJump); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseChain(%q) = %v, want %v", tt.proxyJump, got, tt.want)
+		}
+	}
+}
+
+// testServer 只支持密码认证和 direct-tcpip 转发的 SSH 服务端
+type testServer struct {
+	addr   string
+	closed chan struct{} // 每关闭一个连接发送一次
+}
+
+func startTestServer(t *testing.T, hostKey ssh.Signer, password string) *testServer {
+	t.Helper()
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, p []byte) (*ssh.Permissions, error) {
+			if string(p) != password {
+				return nil, errors.New("wrong password")
+			}
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &testServer{addr: listener.Addr().String(), closed: make(chan struct{}, 16)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.handle(conn, serverConfig)
+		}
+	}()
+	return server
+}
+
+func (s *testServer) handle(conn net.Conn, serverConfig *ssh.ServerConfig) {
+	serverConn, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	go func() {
+		for newChannel := range chans {
+			if newChannel.ChannelType() != "direct-tcpip" {
+				newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+				continue
+			}
+			var payload struct {
+				Host     string
+				Port     uint32
+				OrigHost string
+				OrigPort uint32
+			}
+			if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+			if err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			channel, channelReqs, err := newChannel.Accept()
+			if err != nil {
+				target.Close()
+				continue
+			}
+			go ssh.DiscardRequests(channelReqs)
+			go func() {
+				io.Copy(channel, target)
+				channel.Close()
+			}()
+			go func() {
+				io.Copy(target, channel)
+				target.Close()
+			}()
+		}
+	}()
+	serverConn.Wait()
+	s.closed <- struct{}{}
+}
+
+// setupHome 使用临时 HOME，并把测试服务端的主机密钥写入 known_hosts，返回使用该 HOME 的认证管理器
+func setupHome(t *testing.T, hostKey ssh.Signer, servers ...*testServer) *auth.AuthManager {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	var lines []byte
+	for _, server := range servers {
+		lines = append(lines, knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, hostKey.PublicKey())+"\n"...)
+	}
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), lines, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return auth.NewAuthManager(filepath.Join(home, ".ssm"))
+}
+
+// savedConfig 为测试服务端保存带密码的连接配置
+func savedConfig(t *testing.T, store config.Store, server *testServer, password string) *config.SSHConfig {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(server.addr)
+	cfg := &config.SSHConfig{Host: host, Username: "test", Port: port, Password: password}
+	if err := store.Put(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func waitClosed(t *testing.T, server *testServer) {
+	t.Helper()
+	select {
+	case <-server.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("connection to %s was not closed", server.addr)
+	}
+}
+
+func TestDialChain(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := startTestServer(t, hostKey, "first-pw")
+	second := startTestServer(t, hostKey, "second-pw")
+	target := startTestServer(t, hostKey, "target-pw")
+	authManager := setupHome(t, hostKey, first, second, target)
+
+	// 每一跳使用自己保存的密码
+	store := config.NewMemoryStore()
+	firstConfig := savedConfig(t, store, first, "first-pw")
+	secondConfig := savedConfig(t, store, second, "second-pw")
+
+	targetHost, targetPort, _ := net.SplitHostPort(target.addr)
+	targetConfig := &config.SSHConfig{
+		Host:      targetHost,
+		Username:  "test",
+		Port:      targetPort,
+		Password:  "target-pw",
+		ProxyJump: firstConfig.GetKey() + "," + secondConfig.GetKey(),
+	}
+	clientConfig, err := authManager.CreateClientConfig(targetConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	client, err := Dial(store, authManager, targetConfig, clientConfig, &out)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	// 连接进度写入调用方指定的输出
+	for _, want := range []string{
+		"Connecting to jump host " + first.addr + "...",
+		"Connecting to jump host " + second.addr + " through previous jump host...",
+		"Connecting to target host " + target.addr + " through jump host...",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Dial() output missing %q in:\n%s", want, out.String())
+		}
+	}
+
+	// 关闭目标连接后，中间的跳板机连接也被关闭
+	client.Close()
+	waitClosed(t, target)
+	waitClosed(t, second)
+	waitClosed(t, first)
+}
+
+func TestDialChainFailureClosesHops(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := startTestServer(t, hostKey, "first-pw")
+	target := startTestServer(t, hostKey, "target-pw")
+	authManager := setupHome(t, hostKey, first, target)
+
+	store := config.NewMemoryStore()
+	firstConfig := savedConfig(t, store, first, "first-pw")
+
+	// 目标主机密码错误且无法交互输入
+	targetHost, targetPort, _ := net.SplitHostPort(target.addr)
+	targetConfig := &config.SSHConfig{
+		Host:      targetHost,
+		Username:  "test",
+		Port:      targetPort,
+		Password:  "wrong",
+		ProxyJump: firstConfig.GetKey(),
+	}
+	clientConfig, err := authManager.CreateClientConfig(targetConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Dial(store, authManager, targetConfig, clientConfig, io.Discard); err == nil {
+		t.Fatal("Dial() expected authentication error")
+	}
+	waitClosed(t, first)
+}
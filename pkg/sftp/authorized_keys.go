@@ -0,0 +1,136 @@
+// pkg/sftp/authorized_keys.go
+package sftp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// 相对于远程用户 HOME 目录的路径
+	remoteSSHDir         = ".ssh"
+	remoteAuthorizedKeys = ".ssh/authorized_keys"
+)
+
+// InstallAuthorizedKey 将公钥追加到远程 ~/.ssh/authorized_keys
+// 已存在相同公钥时不重复追加（返回 false），并确保目录和文件权限满足 sshd 的 StrictModes 要求
+func InstallAuthorizedKey(client *ssh.Client, authorizedKeyLine string) (bool, error) {
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKeyLine))
+	if err != nil {
+		return false, fmt.Errorf("invalid public key: %v", err)
+	}
+
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		return false, fmt.Errorf("failed to create SFTP client: %v", err)
+	}
+	defer sftpClient.Close()
+
+	if err := ensureRemoteMode(sftpClient, remoteSSHDir, true, 0700); err != nil {
+		return false, err
+	}
+
+	content, err := readRemoteFile(sftpClient, remoteAuthorizedKeys)
+	if err != nil {
+		return false, err
+	}
+
+	if containsAuthorizedKey(content, publicKey) {
+		return false, ensureRemoteMode(sftpClient, remoteAuthorizedKeys, false, 0600)
+	}
+
+	_, statErr := sftpClient.Stat(remoteAuthorizedKeys)
+	created := os.IsNotExist(statErr)
+
+	file, err := sftpClient.OpenFile(remoteAuthorizedKeys, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+	if err != nil {
+		return false, fmt.Errorf("failed to open remote %s: %v", remoteAuthorizedKeys, err)
+	}
+	defer file.Close()
+
+	// 部分 SFTP 服务端忽略 O_APPEND，显式移动到文件末尾
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		return false, fmt.Errorf("failed to open remote %s: %v", remoteAuthorizedKeys, err)
+	}
+
+	line := authorizedKeyLine + "\n"
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		line = "\n" + line
+	}
+	if _, err := file.Write([]byte(line)); err != nil {
+		return false, fmt.Errorf("failed to write remote %s: %v", remoteAuthorizedKeys, err)
+	}
+
+	if created {
+		if err := sftpClient.Chmod(remoteAuthorizedKeys, 0600); err != nil {
+			return true, fmt.Errorf("failed to set permissions of remote %s: %v", remoteAuthorizedKeys, err)
+		}
+		return true, nil
+	}
+	return true, ensureRemoteMode(sftpClient, remoteAuthorizedKeys, false, 0600)
+}
+
+// ensureRemoteMode 创建远程目录（如需要），新建时设置为 mode，已存在时去掉组和其他用户的写权限
+func ensureRemoteMode(sftpClient *sftp.Client, path string, isDir bool, mode os.FileMode) error {
+	info, err := sftpClient.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to stat remote %s: %v", path, err)
+		}
+		if !isDir {
+			return nil
+		}
+		if err := sftpClient.Mkdir(path); err != nil {
+			return fmt.Errorf("failed to create remote %s: %v", path, err)
+		}
+		return sftpClient.Chmod(path, mode)
+	}
+
+	if info.Mode().Perm()&0022 != 0 {
+		if err := sftpClient.Chmod(path, info.Mode().Perm()&^0022); err != nil {
+			return fmt.Errorf("failed to fix permissions of remote %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// readRemoteFile 读取远程文件，文件不存在时返回空
+func readRemoteFile(sftpClient *sftp.Client, path string) ([]byte, error) {
+	file, err := sftpClient.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open remote %s: %v", path, err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote %s: %v", path, err)
+	}
+	return content, nil
+}
+
+// containsAuthorizedKey 检查 authorized_keys 内容中是否已包含该公钥（忽略注释和选项）
+func containsAuthorizedKey(content []byte, publicKey ssh.PublicKey) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		key, _, _, _, err := ssh.ParseAuthorizedKey(line)
+		if err == nil && bytes.Equal(key.Marshal(), publicKey.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
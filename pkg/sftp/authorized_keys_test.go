@@ -0,0 +1,194 @@
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+func newPublicKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func authorizedKeyLine(key ssh.PublicKey, comment string) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))) + " " + comment
+}
+
+func TestContainsAuthorizedKey(t *testing.T) {
+	key := newPublicKey(t)
+	other := newPublicKey(t)
+
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"empty", "", false},
+		{"same key", authorizedKeyLine(key, "me@host") + "\n", true},
+		{"different comment", authorizedKeyLine(key, "other") + "\n", true},
+		{"with options", `no-pty,from="10.0.0.1" ` + authorizedKeyLine(key, "me") + "\n", true},
+		{"other key", authorizedKeyLine(other, "me") + "\n", false},
+		{"commented out", "# " + authorizedKeyLine(key, "me") + "\n", false},
+		{"among others", "garbage\n\n" + authorizedKeyLine(other, "a") + "\n  " + authorizedKeyLine(key, "b"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsAuthorizedKey([]byte(tt.content), key); got != tt.want {
+				t.Errorf("containsAuthorizedKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// startSFTPServer 启动以 home 为工作目录的 SFTP 服务端，返回已连接的客户端
+func startSFTPServer(t *testing.T, home string) *ssh.Client {
+	t.Helper()
+	_, hostPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(hostPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		_, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+		for newChannel := range chans {
+			channel, requests, err := newChannel.Accept()
+			if err != nil {
+				continue
+			}
+			go func() {
+				for req := range requests {
+					ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+					req.Reply(ok, nil)
+					if !ok {
+						continue
+					}
+					server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(home))
+					if err != nil {
+						channel.Close()
+						return
+					}
+					server.Serve()
+					channel.Close()
+				}
+			}()
+		}
+	}()
+
+	client, err := ssh.Dial("tcp", listener.Addr().String(), &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestInstallAuthorizedKey(t *testing.T) {
+	home := t.TempDir()
+	client := startSFTPServer(t, home)
+	line := authorizedKeyLine(newPublicKey(t), "me@host")
+
+	added, err := InstallAuthorizedKey(client, line)
+	if err != nil || !added {
+		t.Fatalf("InstallAuthorizedKey() = %v, %v, want true", added, err)
+	}
+
+	path := filepath.Join(home, ".ssh", "authorized_keys")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != line+"\n" {
+		t.Errorf("authorized_keys = %q, want %q", content, line+"\n")
+	}
+	if info, _ := os.Stat(filepath.Dir(path)); info.Mode().Perm() != 0700 {
+		t.Errorf(".ssh mode = %v, want 0700", info.Mode().Perm())
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("authorized_keys mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// 再次安装同一公钥不重复追加
+	added, err = InstallAuthorizedKey(client, line)
+	if err != nil || added {
+		t.Fatalf("InstallAuthorizedKey() again = %v, %v, want false", added, err)
+	}
+	if again, _ := os.ReadFile(path); string(again) != string(content) {
+		t.Errorf("authorized_keys changed on reinstall: %q", again)
+	}
+}
+
+func TestInstallAuthorizedKeyAppends(t *testing.T) {
+	home := t.TempDir()
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.Mkdir(sshDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	existing := authorizedKeyLine(newPublicKey(t), "old")
+	path := filepath.Join(sshDir, "authorized_keys")
+	// 没有结尾换行且对组可写
+	if err := os.WriteFile(path, []byte(existing), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0664); err != nil {
+		t.Fatal(err)
+	}
+
+	client := startSFTPServer(t, home)
+	line := authorizedKeyLine(newPublicKey(t), "new")
+	if added, err := InstallAuthorizedKey(client, line); err != nil || !added {
+		t.Fatalf("InstallAuthorizedKey() = %v, %v, want true", added, err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if want := existing + "\n" + line + "\n"; string(content) != want {
+		t.Errorf("authorized_keys = %q, want %q", content, want)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+		t.Errorf("authorized_keys mode = %v, want 0644", info.Mode().Perm())
+	}
+}
+
+func TestInstallAuthorizedKeyInvalid(t *testing.T) {
+	if _, err := InstallAuthorizedKey(nil, "not a key"); err == nil {
+		t.Error("InstallAuthorizedKey() with invalid key succeeded")
+	}
+}
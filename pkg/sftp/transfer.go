@@ -14,6 +14,7 @@ import (
 
 	"github.com/wuxs/ssm/pkg/auth"
 	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/jump"
 )
 
 // TransferOptions 传输选项
@@ -25,12 +26,21 @@ type TransferOptions struct {
 
 // TransferManager SFTP传输管理器
 type TransferManager struct {
-	sshClient *ssh.Client // 保存SSH客户端引用，用于执行命令
+	store       config.Store      // 跳板机配置的来源
+	authManager *auth.AuthManager // 连接使用的认证管理器
+	sshClient   *ssh.Client       // 保存SSH客户端引用，用于执行命令
+	files       int               // 已传输的文件数
+	bytes       int64             // 已传输的字节数（包括失败时已写入的部分）
 }
 
 // NewTransferManager 创建新的传输管理器
-func NewTransferManager() *TransferManager {
-	return &TransferManager{}
+func NewTransferManager(store config.Store, authManager *auth.AuthManager) *TransferManager {
+	return &TransferManager{store: store, authManager: authManager}
+}
+
+// Stats 获取已传输的文件数和字节数
+func (tm *TransferManager) Stats() (files int, bytes int64) {
+	return tm.files, tm.bytes
 }
 
 // LocationInterface 位置接口
@@ -78,124 +88,13 @@ func (tm *TransferManager) Transfer(sshConfig *config.SSHConfig, source, destina
 // establishSSHConnection 建立SSH连接
 func (tm *TransferManager) establishSSHConnection(cfg *config.SSHConfig) (*ssh.Client, error) {
 	// 创建SSH客户端配置
-	clientConfig, err := auth.CreateClientConfig(cfg)
+	clientConfig, err := tm.authManager.CreateClientConfig(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create SSH config: %v", err)
 	}
 
-	// 连接SSH服务器（支持跳板机）
-	return tm.connectWithJump(cfg, clientConfig)
-}
-
-// connectWithJump 支持跳板机的连接
-func (tm *TransferManager) connectWithJump(cfg *config.SSHConfig, clientConfig *ssh.ClientConfig) (*ssh.Client, error) {
-	// 如果没有跳板机，直接连接
-	if cfg.ProxyJump == "" {
-		addr := cfg.Host + ":" + cfg.Port
-		if cfg.Host != "" && cfg.Port != "" {
-			fmt.Printf("Connecting to %s...\n", addr)
-		}
-		return ssh.Dial("tcp", addr, clientConfig)
-	}
-
-	// 解析跳板机配置
-	jumpConfig := tm.parseJumpConfig(cfg.ProxyJump)
-
-	// 创建跳板机SSH配置
-	jumpClientConfig, err := auth.CreateClientConfig(jumpConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create jump host SSH config: %v", err)
-	}
-
-	// 连接跳板机
-	jumpAddr := jumpConfig.Host + ":" + jumpConfig.Port
-	fmt.Printf("Connecting to jump host %s...\n", jumpAddr)
-	jumpClient, err := ssh.Dial("tcp", jumpAddr, jumpClientConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to jump host: %v", err)
-	}
-
-	// 保存跳板机配置（如果连接成功）
-	jumpConfig.UpdateLastUsed()
-	if err := config.SaveConfig(jumpConfig); err != nil {
-		fmt.Printf("Warning: Failed to save jump host config: %v\n", err)
-	}
-
-	// 通过跳板机连接到目标服务器
-	targetAddr := cfg.Host + ":" + cfg.Port
-	fmt.Printf("Connecting to target host %s through jump host...\n", targetAddr)
-	targetConn, err := jumpClient.Dial("tcp", targetAddr)
-	if err != nil {
-		jumpClient.Close()
-		return nil, fmt.Errorf("failed to dial target through jump host: %v", err)
-	}
-
-	// 建立SSH连接
-	ncc, chans, reqs, err := ssh.NewClientConn(targetConn, targetAddr, clientConfig)
-	if err != nil {
-		targetConn.Close()
-		jumpClient.Close()
-		return nil, fmt.Errorf("failed to establish SSH connection: %v", err)
-	}
-
-	return ssh.NewClient(ncc, chans, reqs), nil
-}
-
-// parseJumpConfig 解析跳板机配置
-func (tm *TransferManager) parseJumpConfig(proxyJump string) *config.SSHConfig {
-	// 这里需要导入utils包的解析函数
-	username := ""
-	hostname := ""
-	port := "22"
-
-	// 简单解析跳板机地址
-	if strings.Contains(proxyJump, "@") {
-		parts := strings.Split(proxyJump, "@")
-		if len(parts) == 2 {
-			username = parts[0]
-			hostPort := parts[1]
-			if strings.Contains(hostPort, ":") {
-				hostPortParts := strings.Split(hostPort, ":")
-				if len(hostPortParts) == 2 {
-					hostname = hostPortParts[0]
-					port = hostPortParts[1]
-				}
-			} else {
-				hostname = hostPort
-			}
-		}
-	} else {
-		if strings.Contains(proxyJump, ":") {
-			parts := strings.Split(proxyJump, ":")
-			if len(parts) == 2 {
-				hostname = parts[0]
-				port = parts[1]
-			}
-		} else {
-			hostname = proxyJump
-		}
-	}
-
-	// 获取默认用户名
-	if username == "" {
-		if user := os.Getenv("USER"); user != "" {
-			username = user
-		} else {
-			username = "root"
-		}
-	}
-
-	key := fmt.Sprintf("%s@%s:%s", username, hostname, port)
-	// 先尝试从保存的配置中查找
-	if jumpConfig, exists := config.Get(key); exists {
-		return jumpConfig
-	}
-
-	return &config.SSHConfig{
-		Host:     hostname,
-		Username: username,
-		Port:     port,
-	}
+	// 连接SSH服务器（支持跳板机链）
+	return jump.Dial(tm.store, tm.authManager, cfg, clientConfig, os.Stdout)
 }
 
 // uploadFile 上传文件或目录
@@ -280,6 +179,7 @@ func (tm *TransferManager) uploadSingleFile(sftpClient *sftp.Client, localPath,
 	// 复制文件内容
 	fileSize := localInfo.Size()
 	written, err := tm.copyWithProgress(remoteFile, localFile, fileSize, options.Verbose)
+	tm.bytes += written
 	if err != nil {
 		return fmt.Errorf("failed to copy file content: %v", err)
 	}
@@ -287,6 +187,7 @@ func (tm *TransferManager) uploadSingleFile(sftpClient *sftp.Client, localPath,
 	if options.Verbose {
 		fmt.Printf("✓ Uploaded %d bytes successfully\n", written)
 	}
+	tm.files++
 
 	// 保持文件属性
 	if options.Preserve {
@@ -345,6 +246,7 @@ func (tm *TransferManager) downloadSingleFile(sftpClient *sftp.Client, remotePat
 	// 复制文件内容
 	fileSize := remoteInfo.Size()
 	written, err := tm.copyWithProgress(localFile, remoteFile, fileSize, options.Verbose)
+	tm.bytes += written
 	if err != nil {
 		return fmt.Errorf("failed to copy file content: %v", err)
 	}
@@ -352,6 +254,7 @@ func (tm *TransferManager) downloadSingleFile(sftpClient *sftp.Client, remotePat
 	if options.Verbose {
 		fmt.Printf("✓ Downloaded %d bytes successfully\n", written)
 	}
+	tm.files++
 
 	// 保持文件属性
 	if options.Preserve {
@@ -532,8 +435,8 @@ func (tm *TransferManager) copyWithProgress(dst io.Writer, src io.Reader, totalS
 			if percent != lastPercent && (percent%5 == 0 || percent == 100) {
 				fmt.Printf("  %d%% (%s/%s)\n",
 					percent,
-					tm.formatBytes(written),
-					tm.formatBytes(totalSize))
+					FormatBytes(written),
+					FormatBytes(totalSize))
 				lastPercent = percent
 			}
 		}
@@ -548,8 +451,8 @@ func (tm *TransferManager) copyWithProgress(dst io.Writer, src io.Reader, totalS
 	return written, nil
 }
 
-// formatBytes 格式化字节数为可读的字符串
-func (tm *TransferManager) formatBytes(bytes int64) string {
+// FormatBytes 格式化字节数为可读的字符串
+func FormatBytes(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
 		return fmt.Sprintf("%d B", bytes)
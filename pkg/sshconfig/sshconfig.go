@@ -0,0 +1,397 @@
+// pkg/sshconfig/sshconfig.go
+package sshconfig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+const (
+	maxIncludeDepth = 16 // Include 嵌套的最大深度，防止循环引用
+	maxJumpDepth    = 8  // ProxyJump 引用其他主机的最大深度
+
+	// annotationPrefix ssm 专有设置（分组、标签）以注释形式写在 Host 块中，ssh 会忽略，
+	// 例如 "# ssm:tags prod,web"
+	annotationPrefix = "# ssm:"
+)
+
+// option 表示一条配置项及其所在 Host 块的匹配模式
+type option struct {
+	patterns []string
+	key      string // 小写的关键字
+	args     []string
+}
+
+// File 表示解析后的 OpenSSH 客户端配置（已展开 Include）
+type File struct {
+	Path     string
+	Warnings []string // 不支持的配置（如 Match 块）
+
+	options []option
+	hosts   []string // 不含通配符的 Host 名称，按出现顺序
+	baseDir string   // Include 相对路径的基准目录
+}
+
+// Parse 解析 OpenSSH 客户端配置文件
+func Parse(path string) (*File, error) {
+	path = expandHome(path)
+	f := &File{Path: path, baseDir: filepath.Dir(path)}
+	if err := f.parseFile(path, []string{"*"}, 0); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetDefaultPath 获取默认的 OpenSSH 客户端配置路径（~/.ssh/config）
+func GetDefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssh", "config")
+}
+
+// parseFile 解析单个文件，patterns 为文件开头配置项所属的 Host 模式
+func (f *File) parseFile(path string, patterns []string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("too many nested includes at %s", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open ssh config: %v", err)
+	}
+	defer file.Close()
+
+	current := patterns
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		// 跳过 ssm 自己导出的文件，避免导入时与已保存的配置重复
+		if lineNo == 1 && depth > 0 && scanner.Text() == ManagedHeader {
+			return nil
+		}
+
+		key, args, err := splitLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", path, lineNo, err)
+		}
+		if key == "" {
+			continue
+		}
+
+		switch key {
+		case "host":
+			current = args
+			for _, name := range args {
+				if isConcrete(name) && !containsFold(f.hosts, name) {
+					f.hosts = append(f.hosts, name)
+				}
+			}
+		case "match":
+			// Match 块的条件无法在导入时求值，整个块忽略
+			current = nil
+			f.Warnings = append(f.Warnings, fmt.Sprintf("%s:%d: Match blocks are not supported, skipped", path, lineNo))
+		case "include":
+			if current == nil {
+				continue
+			}
+			for _, pattern := range args {
+				matches, err := filepath.Glob(f.includePath(pattern))
+				if err != nil {
+					return fmt.Errorf("%s:%d: invalid include pattern %q: %v", path, lineNo, pattern, err)
+				}
+				sort.Strings(matches)
+				for _, match := range matches {
+					if err := f.parseFile(match, current, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			if current != nil {
+				f.options = append(f.options, option{patterns: current, key: key, args: args})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ssh config %s: %v", path, err)
+	}
+	return nil
+}
+
+// includePath 展开 Include 路径，相对路径基于主配置文件所在目录（对 ~/.ssh/config 即 ~/.ssh）
+func (f *File) includePath(pattern string) string {
+	pattern = expandHome(pattern)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(f.baseDir, pattern)
+	}
+	return pattern
+}
+
+// Hosts 返回配置中的具体主机名（通配符模式只作为默认值，不单独导入）
+func (f *File) Hosts() []string {
+	return f.hosts
+}
+
+// Lookup 获取主机的配置项，与 OpenSSH 一致，第一个匹配的值生效
+func (f *File) Lookup(alias, key string) []string {
+	key = strings.ToLower(key)
+	for _, opt := range f.options {
+		if opt.key == key && matchPatterns(alias, opt.patterns) {
+			return opt.args
+		}
+	}
+	return nil
+}
+
+// lookupFirst 获取主机配置项的第一个参数
+func (f *File) lookupFirst(alias, key string) string {
+	if args := f.Lookup(alias, key); len(args) > 0 {
+		return args[0]
+	}
+	return ""
+}
+
+// Resolve 计算主机的完整配置并转换为 ssm 的连接配置
+func (f *File) Resolve(alias string) (*config.SSHConfig, error) {
+	cfg := f.resolveAddress(alias)
+
+	// Host 名称与地址不同时作为别名导入
+	if !strings.EqualFold(alias, cfg.Host) && config.ValidateAlias(alias) == nil {
+		cfg.Alias = alias
+	}
+
+	if identity := f.lookupFirst(alias, "IdentityFile"); identity != "" {
+		cfg.PrivateKey = expandTokens(identity, alias, cfg)
+	}
+	if certificate := f.lookupFirst(alias, "CertificateFile"); certificate != "" {
+		cfg.Certificate = expandTokens(certificate, alias, cfg)
+	}
+	if group := f.lookupFirst(alias, "ssm:group"); config.ValidateGroup(group) == nil {
+		cfg.Group = group
+	}
+	for _, tag := range f.Lookup(alias, "ssm:tags") {
+		if config.ValidateTag(tag) == nil {
+			cfg.AddTags(tag)
+		}
+	}
+	cfg.IdentitiesOnly = isYes(f.lookupFirst(alias, "IdentitiesOnly"))
+	cfg.ForwardAgent = isYes(f.lookupFirst(alias, "ForwardAgent"))
+	cfg.Ciphers = f.lookupFirst(alias, "Ciphers")
+	cfg.MACs = f.lookupFirst(alias, "MACs")
+	cfg.KeyExchanges = f.lookupFirst(alias, "KexAlgorithms")
+	cfg.HostKeyAlgorithms = f.lookupFirst(alias, "HostKeyAlgorithms")
+
+	proxyJump, err := f.resolveProxyJump(alias, 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ProxyJump = proxyJump
+
+	return cfg, nil
+}
+
+// resolveAddress 计算主机的 HostName、User、Port
+func (f *File) resolveAddress(alias string) *config.SSHConfig {
+	cfg := &config.SSHConfig{
+		Host:     alias,
+		Username: utils.GetDefaultUsername(f.lookupFirst(alias, "User")),
+		Port:     utils.GetDefaultPort(f.lookupFirst(alias, "Port")),
+	}
+	if hostname := f.lookupFirst(alias, "HostName"); hostname != "" {
+		cfg.Host = expandTokens(hostname, alias, cfg)
+	}
+	return cfg
+}
+
+// resolveProxyJump 将 ProxyJump 转换为 user@host:port 形式的跳板机链，
+// 跳板机本身是配置中的主机时使用其 HostName/User/Port 及其自身的 ProxyJump
+func (f *File) resolveProxyJump(alias string, depth int) (string, error) {
+	if depth > maxJumpDepth {
+		return "", fmt.Errorf("ProxyJump loop detected at %s", alias)
+	}
+
+	spec := f.lookupFirst(alias, "ProxyJump")
+	if spec == "" || strings.EqualFold(spec, "none") {
+		return "", nil
+	}
+
+	var hops []string
+	for _, hop := range strings.Split(spec, ",") {
+		hop = strings.TrimPrefix(strings.TrimSpace(hop), "ssh://")
+		if hop == "" {
+			continue
+		}
+
+		username, hostname, port := splitHop(hop)
+		jump := f.resolveAddress(hostname)
+		if username != "" {
+			jump.Username = username
+		}
+		if port != "" {
+			jump.Port = port
+		}
+
+		// 跳板机自身也需要经过跳板机时，先连接它的跳板机
+		parent, err := f.resolveProxyJump(hostname, depth+1)
+		if err != nil {
+			return "", err
+		}
+		if parent != "" {
+			hops = append(hops, parent)
+		}
+		hops = append(hops, jump.GetKey())
+	}
+	return strings.Join(hops, ","), nil
+}
+
+// splitHop 拆分 [user@]host[:port]，未指定的部分返回空字符串
+func splitHop(hop string) (username, hostname, port string) {
+	if at := strings.LastIndex(hop, "@"); at != -1 {
+		username = hop[:at]
+		hop = hop[at+1:]
+	}
+	hostname = hop
+	if colon := strings.LastIndex(hop, ":"); colon != -1 {
+		hostname = hop[:colon]
+		port = hop[colon+1:]
+	}
+	return username, hostname, port
+}
+
+// splitLine 拆分配置行为小写关键字和参数，支持 "Key value"、"Key=value" 和双引号
+func splitLine(line string) (string, []string, error) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, annotationPrefix) {
+		key, args := splitAnnotation(line)
+		return key, args, nil
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil, nil
+	}
+
+	end := strings.IndexAny(line, " \t=")
+	if end == -1 {
+		return strings.ToLower(line), nil, nil
+	}
+	key := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t")
+
+	args, err := splitArgs(rest)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, args, nil
+}
+
+// splitAnnotation 解析 "# ssm:<名称> 值1,值2" 形式的注释，关键字为 ssm:<名称>
+func splitAnnotation(line string) (string, []string) {
+	fields := strings.FieldsFunc(line[len(annotationPrefix):], func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return "ssm:" + strings.ToLower(fields[0]), fields[1:]
+}
+
+// splitArgs 按空白拆分参数，双引号内的空白保留，# 开始的部分为注释
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inQuote, hasArg := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			hasArg = true
+		case !inQuote && (r == ' ' || r == '\t'):
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		case !inQuote && r == '#' && !hasArg:
+			return args, nil
+		default:
+			current.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// matchPatterns 检查主机名是否匹配 Host 模式列表（支持 * ? 通配和 ! 排除）
+func matchPatterns(host string, patterns []string) bool {
+	host = strings.ToLower(host)
+	matched := false
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			if utils.WildcardMatch(negated, host) {
+				return false
+			}
+		} else if utils.WildcardMatch(pattern, host) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// isConcrete 是否为具体主机名（不含通配符和排除）
+func isConcrete(name string) bool {
+	return !strings.ContainsAny(name, "*?!")
+}
+
+// containsFold 忽略大小写检查列表是否包含指定字符串
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isYes OpenSSH 布尔值
+func isYes(value string) bool {
+	return strings.EqualFold(value, "yes") || strings.EqualFold(value, "true")
+}
+
+// expandTokens 展开路径中的 ~ 和常用的 % 占位符
+func expandTokens(value, alias string, cfg *config.SSHConfig) string {
+	replacer := strings.NewReplacer(
+		"%%", "%",
+		"%d", os.Getenv("HOME"),
+		"%h", cfg.Host,
+		"%n", alias,
+		"%p", cfg.Port,
+		"%r", cfg.Username,
+		"%u", utils.GetDefaultUsername(""),
+	)
+	return expandHome(replacer.Replace(value))
+}
+
+// expandHome 展开路径开头的 ~
+func expandHome(path string) string {
+	if path == "~" {
+		return os.Getenv("HOME")
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(os.Getenv("HOME"), rest)
+	}
+	return path
+}
@@ -0,0 +1,289 @@
+// pkg/sshconfig/sshconfig_test.go
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wuxs/ssm/pkg/config"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseAndResolve(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config"), `
+Include conf.d/*.conf
+
+Host web web.example.com
+    HostName 10.0.0.10
+    User deploy
+    IdentityFile "/keys/web key"
+    ProxyJump bastion
+
+Host db
+    HostName=10.0.0.20
+    Port 2222
+    ProxyJump ops@bastion:2200,inner
+
+Host inner
+    HostName 10.1.0.1
+    ProxyJump bastion
+
+Match host *.internal
+    User ignored
+
+Host * !legacy
+    User admin
+    Port 22
+    ForwardAgent yes
+`)
+	writeFile(t, filepath.Join(dir, "conf.d", "bastion.conf"), `
+Host bastion
+    HostName bastion.example.com
+    User jump
+`)
+	// ssm 导出的文件不会被导入
+	writeFile(t, filepath.Join(dir, "conf.d", "ssm.conf"), ManagedHeader+`
+Host exported
+    HostName 10.9.9.9
+`)
+
+	f, err := Parse(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	wantHosts := []string{"bastion", "web", "web.example.com", "db", "inner"}
+	if !reflect.DeepEqual(f.Hosts(), wantHosts) {
+		t.Errorf("Hosts() = %v, want %v", f.Hosts(), wantHosts)
+	}
+	if len(f.Warnings) != 1 {
+		t.Errorf("Warnings = %v, want one Match warning", f.Warnings)
+	}
+
+	tests := []struct {
+		alias         string
+		wantKey       string
+		wantKeyFile   string
+		wantJump      string
+		wantForwarded bool
+	}{
+		{"bastion", "jump@bastion.example.com:22", "", "", true},
+		{"web", "deploy@10.0.0.10:22", "/keys/web key", "jump@bastion.example.com:22", true},
+		{"db", "admin@10.0.0.20:2222", "", "ops@bastion.example.com:2200,jump@bastion.example.com:22,admin@10.1.0.1:22", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			cfg, err := f.Resolve(tt.alias)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if cfg.GetKey() != tt.wantKey {
+				t.Errorf("key = %v, want %v", cfg.GetKey(), tt.wantKey)
+			}
+			if cfg.PrivateKey != tt.wantKeyFile {
+				t.Errorf("PrivateKey = %v, want %v", cfg.PrivateKey, tt.wantKeyFile)
+			}
+			if cfg.ProxyJump != tt.wantJump {
+				t.Errorf("ProxyJump = %v, want %v", cfg.ProxyJump, tt.wantJump)
+			}
+			if cfg.ForwardAgent != tt.wantForwarded {
+				t.Errorf("ForwardAgent = %v, want %v", cfg.ForwardAgent, tt.wantForwarded)
+			}
+		})
+	}
+}
+
+func TestResolveProxyJumpLoop(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config"), `
+Host a
+    ProxyJump b
+Host b
+    ProxyJump a
+`)
+
+	f, err := Parse(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, err := f.Resolve("a"); err == nil {
+		t.Error("Resolve() expected loop error")
+	}
+}
+
+func TestMatchPatterns(t *testing.T) {
+	tests := []struct {
+		host     string
+		patterns []string
+		want     bool
+	}{
+		{"web1", []string{"web?"}, true},
+		{"web10", []string{"web?"}, false},
+		{"db.example.com", []string{"*.example.com"}, true},
+		{"legacy", []string{"*", "!legacy"}, false},
+		{"WEB", []string{"web"}, true},
+	}
+	for _, tt := range tests {
+		if got := matchPatterns(tt.host, tt.patterns); got != tt.want {
+			t.Errorf("matchPatterns(%q, %v) = %v, want %v", tt.host, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestRenderRoundTrip(t *testing.T) {
+	configs := []config.SSHConfig{
+		{Host: "10.0.0.1", Username: "deploy", Port: "2222", PrivateKey: "/keys/deploy key", ProxyJump: "jump@bastion:22", LastUsed: "2025-01-02T00:00:00Z",
+			Group: "databases", Tags: []string{"prod", "web"}},
+		{Host: "db", Username: "root", Port: "22", ForwardAgent: true, Password: "secret"},
+		{Host: "db", Username: "admin", Port: "22"},
+		{Host: "bastion", Username: "jump", Port: "22"},
+	}
+
+	content := Render(configs)
+	if strings.Contains(content, "secret") {
+		t.Errorf("Render() exported a password:\n%s", content)
+	}
+
+	// 输出与顺序无关
+	reversed := []config.SSHConfig{configs[3], configs[2], configs[1], configs[0]}
+	if Render(reversed) != content {
+		t.Errorf("Render() output depends on input order")
+	}
+
+	path := filepath.Join(t.TempDir(), "ssm.conf")
+	writeFile(t, path, content)
+	f, err := Parse(path)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// 按配置键排序
+	wantHosts := []string{"admin-db", "10.0.0.1", "bastion", "root-db"}
+	if !reflect.DeepEqual(f.Hosts(), wantHosts) {
+		t.Errorf("Hosts() = %v, want %v", f.Hosts(), wantHosts)
+	}
+
+	for i, alias := range wantHosts {
+		want := []config.SSHConfig{configs[2], configs[0], configs[3], configs[1]}[i]
+		got, err := f.Resolve(alias)
+		if err != nil {
+			t.Fatalf("Resolve(%s) error = %v", alias, err)
+		}
+		if got.GetKey() != want.GetKey() || got.PrivateKey != want.PrivateKey ||
+			got.ProxyJump != want.ProxyJump || got.ForwardAgent != want.ForwardAgent ||
+			got.Group != want.Group || !reflect.DeepEqual(got.Tags, want.Tags) {
+			t.Errorf("Resolve(%s) = %+v, want %+v", alias, got, want)
+		}
+	}
+}
+
+func TestWriteManaged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssm.conf")
+	content := Render(nil)
+
+	if changed, err := WriteManaged(path, content, false); err != nil || !changed {
+		t.Fatalf("WriteManaged() = %v, %v, want true, nil", changed, err)
+	}
+	if changed, err := WriteManaged(path, content, false); err != nil || changed {
+		t.Fatalf("WriteManaged() again = %v, %v, want false, nil", changed, err)
+	}
+
+	writeFile(t, path, "Host mine\n")
+	if _, err := WriteManaged(path, content, false); err == nil {
+		t.Error("WriteManaged() overwrote a file not generated by ssm")
+	}
+	if _, err := WriteManaged(path, content, true); err != nil {
+		t.Errorf("WriteManaged(force) error = %v", err)
+	}
+}
+
+func TestEnsureInclude(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+	original := "Host mine\n    User me\n"
+	writeFile(t, path, original)
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if added, err := EnsureInclude(path, "~/.ssh/ssm.conf"); err != nil || !added {
+		t.Fatalf("EnsureInclude() = %v, %v, want true, nil", added, err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "Include ~/.ssh/ssm.conf\n\n" + original; string(data) != want {
+		t.Errorf("config = %q, want %q", data, want)
+	}
+	if backup, _ := os.ReadFile(path + ".bak"); string(backup) != original {
+		t.Errorf("backup = %q, want %q", backup, original)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+		t.Errorf("config mode = %v, want 0644", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("unexpected files left in %s: %v", dir, entries)
+	}
+
+	if added, err := EnsureInclude(path, "~/.ssh/ssm.conf"); err != nil || added {
+		t.Errorf("EnsureInclude() again = %v, %v, want false, nil", added, err)
+	}
+}
+
+func TestEnsureIncludeSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "ssh_config")
+	writeFile(t, target, "Host mine\n")
+	link := filepath.Join(dir, "config")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks are not available: %v", err)
+	}
+
+	if _, err := EnsureInclude(link, "~/.ssh/ssm.conf"); err != nil {
+		t.Fatalf("EnsureInclude() error = %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("symlink replaced by a regular file: %v", err)
+	}
+	if data, _ := os.ReadFile(target); !strings.HasPrefix(string(data), "Include ~/.ssh/ssm.conf\n") {
+		t.Errorf("symlink target = %q, want Include added", data)
+	}
+}
+
+func TestEnsureIncludeNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ssh", "config")
+	if added, err := EnsureInclude(path, "~/.ssh/ssm.conf"); err != nil || !added {
+		t.Fatalf("EnsureInclude() = %v, %v, want true, nil", added, err)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("backup written for a new file: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("config mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestHostNames(t *testing.T) {
+	configs := []config.SSHConfig{
+		{Alias: "db", Host: "10.0.0.5", Username: "root", Port: "22"},
+		{Host: "db", Username: "admin", Port: "22"},
+		{Host: "web", Username: "deploy", Port: "22"},
+		{Host: "web", Username: "deploy", Port: "2222"},
+	}
+
+	want := []string{"db", "admin-db", "deploy-web-22", "deploy-web-2222"}
+	if got := hostNames(configs); !reflect.DeepEqual(got, want) {
+		t.Errorf("hostNames() = %v, want %v", got, want)
+	}
+}
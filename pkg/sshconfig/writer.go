@@ -0,0 +1,191 @@
+// pkg/sshconfig/writer.go
+package sshconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wuxs/ssm/pkg/config"
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+// ManagedHeader ssm 生成的配置文件的首行，只有带此标记的文件才会被覆盖
+const ManagedHeader = "# Managed by ssm, do not edit. Regenerate with: ssm export ssh-config"
+
+// Render 将连接配置渲染为 OpenSSH 的 Host 块，按配置键排序，相同输入总是得到相同输出
+func Render(configs []config.SSHConfig) string {
+	sorted := make([]config.SSHConfig, len(configs))
+	copy(sorted, configs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetKey() < sorted[j].GetKey()
+	})
+	names := hostNames(sorted)
+
+	var b strings.Builder
+	b.WriteString(ManagedHeader + "\n")
+	for i, cfg := range sorted {
+		fmt.Fprintf(&b, "\nHost %s\n", names[i])
+		writeAnnotation(&b, "group", cfg.Group)
+		writeAnnotation(&b, "tags", strings.Join(cfg.Tags, ","))
+		writeOption(&b, "HostName", cfg.Host)
+		writeOption(&b, "User", cfg.Username)
+		if cfg.Port != "" && cfg.Port != "22" {
+			writeOption(&b, "Port", cfg.Port)
+		}
+		writeOption(&b, "IdentityFile", cfg.PrivateKey)
+		writeOption(&b, "CertificateFile", cfg.Certificate)
+		if cfg.IdentitiesOnly {
+			writeOption(&b, "IdentitiesOnly", "yes")
+		}
+		if cfg.ForwardAgent {
+			writeOption(&b, "ForwardAgent", "yes")
+		}
+		writeOption(&b, "ProxyJump", NormalizeProxyJump(cfg.ProxyJump))
+		writeOption(&b, "Ciphers", cfg.Ciphers)
+		writeOption(&b, "MACs", cfg.MACs)
+		writeOption(&b, "KexAlgorithms", cfg.KeyExchanges)
+		writeOption(&b, "HostKeyAlgorithms", cfg.HostKeyAlgorithms)
+	}
+	return b.String()
+}
+
+// hostNames 为每个配置选择 Host 名称：优先使用别名，其次使用主机名，
+// 同一主机有多个用户或端口（或主机名与别名冲突）时依次使用 user-host、user-host-port 区分
+func hostNames(configs []config.SSHConfig) []string {
+	counts := make(map[string]int)
+	userCounts := make(map[string]int)
+	for _, cfg := range configs {
+		if cfg.Alias != "" {
+			counts[cfg.Alias] += 2 // 别名保留，不能再作为其他配置的名称
+			userCounts[cfg.Alias] += 2
+			continue
+		}
+		counts[cfg.Host]++
+		userCounts[cfg.Username+"-"+cfg.Host]++
+	}
+
+	names := make([]string, len(configs))
+	for i, cfg := range configs {
+		switch {
+		case cfg.Alias != "":
+			names[i] = cfg.Alias
+		case counts[cfg.Host] == 1:
+			names[i] = cfg.Host
+		case userCounts[cfg.Username+"-"+cfg.Host] == 1:
+			names[i] = cfg.Username + "-" + cfg.Host
+		default:
+			names[i] = cfg.Username + "-" + cfg.Host + "-" + cfg.Port
+		}
+	}
+	return names
+}
+
+// NormalizeProxyJump 将跳板机链的每一跳规范为 user@host:port（与 ssm 连接时的解析一致）
+func NormalizeProxyJump(proxyJump string) string {
+	var hops []string
+	for _, hop := range strings.Split(proxyJump, ",") {
+		if hop = strings.TrimSpace(hop); hop == "" {
+			continue
+		}
+		hops = append(hops, utils.GetConfigKey(utils.ParseSSHHost(hop)))
+	}
+	return strings.Join(hops, ",")
+}
+
+// writeOption 写入一行配置，值为空时跳过，包含空白时加引号
+func writeOption(b *strings.Builder, key, value string) {
+	if value == "" {
+		return
+	}
+	if strings.ContainsAny(value, " \t") {
+		value = `"` + value + `"`
+	}
+	fmt.Fprintf(b, "    %s %s\n", key, value)
+}
+
+// writeAnnotation 以注释形式写入 ssm 专有的设置，值为空时跳过
+func writeAnnotation(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, "    %s%s %s\n", annotationPrefix, name, value)
+}
+
+// WriteManaged 写入 ssm 管理的配置文件，内容未变化时不写入。
+// 目标文件已存在但不是 ssm 生成的文件时拒绝覆盖（force 除外）
+func WriteManaged(path, content string, force bool) (changed bool, err error) {
+	path = expandHome(path)
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if string(existing) == content {
+			return false, nil
+		}
+		if !force && !strings.HasPrefix(string(existing), ManagedHeader) {
+			return false, fmt.Errorf("%s was not generated by ssm, use --force to overwrite it", path)
+		}
+	case !os.IsNotExist(err):
+		return false, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return false, fmt.Errorf("failed to create directory: %v", err)
+	}
+	if err := utils.WriteFileAtomic(path, []byte(content), 0600); err != nil {
+		return false, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return true, nil
+}
+
+// EnsureInclude 确保 OpenSSH 配置文件开头包含 Include 指定文件的指令，已包含时不修改。
+// 修改前将原文件备份为 <配置文件>.bak，新内容先写临时文件再重命名
+func EnsureInclude(configPath, includePath string) (added bool, err error) {
+	configPath = expandHome(configPath)
+	// 配置文件是符号链接（如由 dotfiles 管理）时修改链接指向的文件
+	if resolved, err := filepath.EvalSymlinks(configPath); err == nil {
+		configPath = resolved
+	}
+
+	mode := os.FileMode(0600)
+	data, err := os.ReadFile(configPath)
+	exists := err == nil
+	switch {
+	case exists:
+		if info, err := os.Stat(configPath); err == nil {
+			mode = info.Mode().Perm()
+		}
+	case !os.IsNotExist(err):
+		return false, fmt.Errorf("failed to read %s: %v", configPath, err)
+	}
+
+	target := expandHome(includePath)
+	for _, line := range strings.Split(string(data), "\n") {
+		key, args, err := splitLine(line)
+		if err != nil || key != "include" {
+			continue
+		}
+		for _, arg := range args {
+			if expandHome(arg) == target {
+				return false, nil
+			}
+		}
+	}
+
+	// Include 必须在所有 Host 块之前才能对所有主机生效
+	content := fmt.Sprintf("Include %s\n\n", includePath) + string(data)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		return false, fmt.Errorf("failed to create directory: %v", err)
+	}
+	if exists {
+		if err := utils.WriteFileAtomic(configPath+".bak", data, mode); err != nil {
+			return false, fmt.Errorf("failed to back up %s: %v", configPath, err)
+		}
+	}
+	if err := utils.WriteFileAtomic(configPath, []byte(content), mode); err != nil {
+		return false, fmt.Errorf("failed to write %s: %v", configPath, err)
+	}
+	return true, nil
+}
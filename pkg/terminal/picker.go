@@ -0,0 +1,354 @@
+// pkg/terminal/picker.go
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrPickCancelled 用户取消了选择（Esc 或 Ctrl-C）
+var ErrPickCancelled = errors.New("selection cancelled")
+
+// maxPickRows 选择列表最多显示的行数
+const maxPickRows = 15
+
+// PickItem 选择列表中的一项
+type PickItem struct {
+	Label  string // 显示的文本
+	Search string // 模糊搜索的文本，为空时搜索 Label
+}
+
+// key 一次按键
+type key struct {
+	code rune // 可输入的字符，或下面的特殊按键
+}
+
+// 特殊按键，使用 Unicode 私有区避免与输入的字符冲突
+const (
+	keyUp rune = 0xE000 + iota
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyBackspace
+	keyDeleteWord
+	keyClear
+	keyCancel
+)
+
+// IsTerminal 文件是否为终端
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Pick 在终端中显示可模糊搜索的列表：输入字符筛选，上下方向键选择，Enter 确认，
+// Esc 或 Ctrl-C 取消。返回选中项在 items 中的下标。in 和 out 必须是终端
+func (tm *TerminalManager) Pick(in, out *os.File, prompt string, items []PickItem) (int, error) {
+	fd := int(in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return -1, fmt.Errorf("failed to make terminal raw: %v", err)
+	}
+	defer term.Restore(fd, state)
+
+	p := newPicker(items)
+	drawn := 0
+	buf := make([]byte, 256)
+	for {
+		width, height, err := tm.getTerminalSize(int(out.Fd()))
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = 80, 24
+		}
+		drawn = p.render(out, prompt, width, height, drawn)
+
+		n, err := in.Read(buf)
+		if err != nil {
+			clearLines(out, drawn)
+			return -1, err
+		}
+
+		for _, k := range parseKeys(buf[:n]) {
+			switch p.handleKey(k) {
+			case pickDone:
+				clearLines(out, drawn)
+				return p.current(), nil
+			case pickCancelled:
+				clearLines(out, drawn)
+				return -1, ErrPickCancelled
+			}
+		}
+	}
+}
+
+// pickResult 按键处理结果
+type pickResult int
+
+const (
+	pickContinue pickResult = iota
+	pickDone
+	pickCancelled
+)
+
+// picker 选择列表的状态
+type picker struct {
+	items    []PickItem
+	query    []rune
+	matches  []int // 匹配的项在 items 中的下标，按匹配度排序
+	selected int   // 选中项在 matches 中的位置
+	offset   int   // 显示的第一项在 matches 中的位置
+}
+
+func newPicker(items []PickItem) *picker {
+	p := &picker{items: items}
+	p.filter()
+	return p
+}
+
+// current 选中项在 items 中的下标，没有匹配项时为 -1
+func (p *picker) current() int {
+	if len(p.matches) == 0 {
+		return -1
+	}
+	return p.matches[p.selected]
+}
+
+// filter 按输入重新筛选并排序：匹配度高的在前，相同时保持原顺序
+func (p *picker) filter() {
+	query := string(p.query)
+	scores := make(map[int]int)
+	p.matches = p.matches[:0]
+	for i, item := range p.items {
+		text := item.Search
+		if text == "" {
+			text = item.Label
+		}
+		if score, ok := FuzzyMatch(query, text); ok {
+			scores[i] = score
+			p.matches = append(p.matches, i)
+		}
+	}
+	sort.SliceStable(p.matches, func(i, j int) bool {
+		return scores[p.matches[i]] > scores[p.matches[j]]
+	})
+	p.selected, p.offset = 0, 0
+}
+
+// handleKey 处理一次按键
+func (p *picker) handleKey(k key) pickResult {
+	switch k.code {
+	case keyEnter:
+		if len(p.matches) > 0 {
+			return pickDone
+		}
+	case keyCancel:
+		return pickCancelled
+	case keyUp:
+		p.move(-1)
+	case keyDown:
+		p.move(1)
+	case keyPageUp:
+		p.move(-maxPickRows)
+	case keyPageDown:
+		p.move(maxPickRows)
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case keyDeleteWord:
+		query := strings.TrimRightFunc(string(p.query), unicode.IsSpace)
+		if i := strings.LastIndexFunc(query, unicode.IsSpace); i >= 0 {
+			query = query[:i+1]
+		} else {
+			query = ""
+		}
+		p.query = []rune(query)
+		p.filter()
+	case keyClear:
+		p.query = nil
+		p.filter()
+	default:
+		if unicode.IsPrint(k.code) {
+			p.query = append(p.query, k.code)
+			p.filter()
+		}
+	}
+	return pickContinue
+}
+
+// move 移动选中项，停在列表两端
+func (p *picker) move(delta int) {
+	p.selected = max(0, min(len(p.matches)-1, p.selected+delta))
+}
+
+// render 绘制输入行、计数和列表，先清除上次绘制的 drawn 行，返回本次绘制的行数
+func (p *picker) render(w io.Writer, prompt string, width, height, drawn int) int {
+	rows := max(1, min(maxPickRows, height-3, len(p.matches)))
+	if p.selected < p.offset {
+		p.offset = p.selected
+	}
+	if p.selected >= p.offset+rows {
+		p.offset = p.selected - rows + 1
+	}
+
+	var buf bytes.Buffer
+	moveToTop(&buf, drawn)
+	buf.WriteString("\x1b[J")
+
+	lines := 0
+	fmt.Fprintf(&buf, "  %d/%d\r\n", len(p.matches), len(p.items))
+	lines++
+	for i := p.offset; i < len(p.matches) && i < p.offset+rows; i++ {
+		label := truncate(p.items[p.matches[i]].Label, width-3)
+		if i == p.selected {
+			fmt.Fprintf(&buf, "\x1b[7m> %s\x1b[0m\r\n", label)
+		} else {
+			fmt.Fprintf(&buf, "  %s\r\n", label)
+		}
+		lines++
+	}
+	fmt.Fprintf(&buf, "%s%s", prompt, truncate(string(p.query), width-len(prompt)-1))
+
+	w.Write(buf.Bytes())
+	return lines
+}
+
+// moveToTop 移动光标到上次绘制的第一行行首
+func moveToTop(buf *bytes.Buffer, lines int) {
+	buf.WriteString("\r")
+	if lines > 0 {
+		fmt.Fprintf(buf, "\x1b[%dA", lines)
+	}
+}
+
+// clearLines 清除绘制的列表和输入行
+func clearLines(w io.Writer, lines int) {
+	var buf bytes.Buffer
+	moveToTop(&buf, lines)
+	buf.WriteString("\x1b[J")
+	w.Write(buf.Bytes())
+}
+
+// truncate 截断文本，避免超出终端宽度后换行
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// parseKeys 解析原始模式下读到的输入
+func parseKeys(buf []byte) []key {
+	var keys []key
+	for len(buf) > 0 {
+		if buf[0] == 0x1b {
+			var seq string
+			seq, buf = splitEscape(buf)
+			switch seq {
+			case "\x1b":
+				keys = append(keys, key{keyCancel})
+			case "\x1b[A", "\x1bOA":
+				keys = append(keys, key{keyUp})
+			case "\x1b[B", "\x1bOB":
+				keys = append(keys, key{keyDown})
+			case "\x1b[5~":
+				keys = append(keys, key{keyPageUp})
+			case "\x1b[6~":
+				keys = append(keys, key{keyPageDown})
+			}
+			continue
+		}
+
+		code := rune(buf[0])
+		size := 1
+		switch buf[0] {
+		case '\r', '\n':
+			code = keyEnter
+		case 0x7f, 0x08:
+			code = keyBackspace
+		case 0x03, 0x04: // Ctrl-C、Ctrl-D
+			code = keyCancel
+		case 0x10: // Ctrl-P
+			code = keyUp
+		case 0x0e: // Ctrl-N
+			code = keyDown
+		case 0x17: // Ctrl-W
+			code = keyDeleteWord
+		case 0x15: // Ctrl-U
+			code = keyClear
+		default:
+			code, size = utf8.DecodeRune(buf)
+		}
+		keys = append(keys, key{code})
+		buf = buf[size:]
+	}
+	return keys
+}
+
+// splitEscape 拆出开头的转义序列：单独的 Esc、CSI 序列（Esc [ 参数 结束字符）、SS3 序列（Esc O x），
+// 其他情况（如 Alt+字符）取两个字节
+func splitEscape(buf []byte) (string, []byte) {
+	n := 2
+	switch {
+	case len(buf) == 1:
+		n = 1
+	case buf[1] == '[':
+		n = 2
+		for n < len(buf) && buf[n] >= 0x20 && buf[n] <= 0x3f {
+			n++
+		}
+		if n < len(buf) {
+			n++
+		}
+	case buf[1] == 'O':
+		n = min(3, len(buf))
+	}
+	return string(buf[:n]), buf[n:]
+}
+
+// FuzzyMatch 模糊匹配：query 的字符按顺序（不区分大小写）出现在 text 中即匹配。
+// 连续匹配和在单词开头匹配的得分更高，匹配间隔越大得分越低；query 为空时匹配所有文本
+func FuzzyMatch(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(strings.Join(strings.Fields(query), "")))
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	t := []rune(strings.ToLower(text))
+	score, qi, last := 0, 0, -1
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		switch {
+		case last >= 0 && ti == last+1:
+			score += 8
+		case ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]):
+			score += 6
+		default:
+			score += 1
+		}
+		if last >= 0 {
+			score -= min(ti-last-1, 5)
+		}
+		last = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return score, true
+}
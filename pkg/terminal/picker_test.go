@@ -0,0 +1,75 @@
+// pkg/terminal/picker_test.go
+package terminal
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, text string
+		want        bool
+	}{
+		{"", "anything", true},
+		{"pdb", "prod-db root@10.0.0.1:22", true},
+		{"PDB", "prod-db", true},
+		{"bdp", "prod-db", false},
+		{"prod db", "prod-db", true},
+	}
+	for _, tt := range tests {
+		if _, got := FuzzyMatch(tt.query, tt.text); got != tt.want {
+			t.Errorf("FuzzyMatch(%q, %q) = %v, want %v", tt.query, tt.text, got, tt.want)
+		}
+	}
+
+	consecutive, _ := FuzzyMatch("web", "web1 root@10.0.0.1:22")
+	scattered, _ := FuzzyMatch("web", "wiki-e-b root@10.0.0.2:22")
+	if consecutive <= scattered {
+		t.Errorf("consecutive match scored %d, scattered %d, want consecutive higher", consecutive, scattered)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("a\x1b[A\x1b[B\x1b[1;5C\x7f\r\x1b"))
+	want := []key{{'a'}, {keyUp}, {keyDown}, {keyBackspace}, {keyEnter}, {keyCancel}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestPicker(t *testing.T) {
+	p := newPicker([]PickItem{
+		{Label: "web1"},
+		{Label: "db1", Search: "db1 prod"},
+		{Label: "web2", Search: "web2 staging"},
+	})
+
+	for _, k := range parseKeys([]byte("\x1b[B\x1b[B\x1b[B")) {
+		p.handleKey(k)
+	}
+	if p.current() != 2 {
+		t.Errorf("current() = %d after moving past the end, want 2", p.current())
+	}
+
+	for _, k := range parseKeys([]byte("prod")) {
+		p.handleKey(k)
+	}
+	if p.current() != 1 || len(p.matches) != 1 {
+		t.Errorf("search prod matched %v, want only db1", p.matches)
+	}
+
+	p.handleKey(key{keyClear})
+	for _, k := range parseKeys([]byte("xyz")) {
+		p.handleKey(k)
+	}
+	if result := p.handleKey(key{keyEnter}); result != pickContinue || p.current() != -1 {
+		t.Errorf("Enter without matches = %v, want to keep picking", result)
+	}
+
+	var out bytes.Buffer
+	if lines := p.render(&out, "> ", 80, 24, 0); lines != 1 {
+		t.Errorf("render() drew %d lines without matches, want 1", lines)
+	}
+}
@@ -4,13 +4,20 @@ package utils
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
-// ParseSSHHost 解析主机信息
+// ParseSSHHost 解析主机信息，未指定的用户和端口使用默认值
 func ParseSSHHost(host string) (username, hostname, port string) {
-	// 解析 user@hostname:port 格式
+	username, hostname, port = SplitSSHHost(host)
+	username = GetDefaultUsername(username)
+	port = GetDefaultPort(port)
+
+	return username, hostname, port
+}
+
+// SplitSSHHost 拆分 user@hostname:port 格式，未指定的部分为空
+func SplitSSHHost(host string) (username, hostname, port string) {
 	if atPos := strings.Index(host, "@"); atPos != -1 {
 		username = host[:atPos]
 		host = host[atPos+1:]
@@ -22,9 +29,6 @@ func ParseSSHHost(host string) (username, hostname, port string) {
 	} else {
 		hostname = host
 	}
-	username = GetDefaultUsername(username)
-	port = GetDefaultPort(port)
-
 	return username, hostname, port
 }
 
@@ -49,14 +53,6 @@ func GetDefaultPort(port string) string {
 	return "22"
 }
 
-// GetDefaultPrivateKeyPath 获取默认私钥路径
-func GetDefaultPrivateKeyPath(privateKeyPath string) string {
-	if privateKeyPath != "" {
-		return privateKeyPath
-	}
-	return filepath.Join(os.Getenv("HOME"), ".ssh", "id_rsa")
-}
-
 func GetConfigKey(username, hostname, port string) string {
 	key := fmt.Sprintf("%s@%s:%s", username, hostname, port)
 	return key
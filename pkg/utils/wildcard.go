@@ -0,0 +1,29 @@
+// pkg/utils/wildcard.go
+package utils
+
+// WildcardMatch 通配符匹配，* 匹配任意字符串，? 匹配单个字符。
+// 遇到 * 时记录回溯位置，不递归，时间复杂度为 O(len(pattern)*len(s))
+func WildcardMatch(pattern, s string) bool {
+	p, i := 0, 0
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case star != -1:
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,37 @@
+// pkg/utils/wildcard_test.go
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"*", "web1.example.com", true},
+		{"web?.example.com", "web1.example.com", true},
+		{"web?.example.com", "web10.example.com", false},
+		{"*.example.com", "web1.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"10.0.*.1", "10.0.20.1", true},
+		{"a*b*c", "aXbYbZc", true},
+		{"a*b*c", "aXbYbZ", false},
+		{"", "", true},
+		{"", "a", false},
+	}
+	for _, tt := range tests {
+		if got := WildcardMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("WildcardMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+
+	// 多个 * 不匹配时不会指数级回溯
+	pattern := strings.Repeat("*a", 30) + "b"
+	if WildcardMatch(pattern, strings.Repeat("a", 60)) {
+		t.Errorf("WildcardMatch(%q) matched a string without b", pattern)
+	}
+}
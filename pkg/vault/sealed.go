@@ -0,0 +1,50 @@
+// pkg/vault/sealed.go
+package vault
+
+import "fmt"
+
+// SealedData 使用口令单独加密的数据（如导出的主机清单），密钥派生参数随数据保存
+type SealedData struct {
+	KDF        KDFParams `json:"kdf"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+// SealWithPassphrase 使用口令加密数据，每次使用新的盐，aad 标识数据的用途
+func SealWithPassphrase(passphrase string, plaintext []byte, aad string) (*SealedData, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+
+	params, err := newKDFParams()
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	item, err := seal(key, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	return &SealedData{KDF: params, Nonce: item.Nonce, Ciphertext: item.Ciphertext}, nil
+}
+
+// OpenWithPassphrase 使用口令解密数据，口令错误或数据被修改时返回 ErrWrongPassphrase；
+// 密钥派生参数与 SealWithPassphrase 使用的不同时在派生密钥前返回错误
+func OpenWithPassphrase(passphrase string, data *SealedData, aad string) ([]byte, error) {
+	if err := data.KDF.validate(); err != nil {
+		return nil, fmt.Errorf("unsupported key derivation: %v", err)
+	}
+
+	key, err := deriveKey(passphrase, data.KDF)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(key, SealedItem{Nonce: data.Nonce, Ciphertext: data.Ciphertext}, aad)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,160 @@
+// pkg/vault/session.go
+package vault
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// sessionAAD 会话密文的附加数据前缀，后接到期时间防止篡改有效期
+const sessionAAD = "ssm-vault-session:"
+
+// session 解锁会话，保存被会话密钥加密后的保险库密钥
+//
+// 会话密钥保存在运行时目录（XDG_RUNTIME_DIR 或临时目录下仅当前用户可访问的目录），
+// 不与保险库放在一起，单独拿到配置目录无法解开会话；运行时目录在注销或重启后被清空。
+type session struct {
+	Key       SealedItem `json:"key"`
+	ExpiresAt string     `json:"expires_at"`
+}
+
+// getSessionPath 获取解锁会话文件路径
+func (v *Vault) getSessionPath() string {
+	return v.path + ".session"
+}
+
+// getSessionKeyPath 获取会话密钥文件路径，按保险库路径区分不同配置目录
+func (v *Vault) getSessionKeyPath() (string, error) {
+	dir, err := runtimeDir()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := filepath.Abs(v.path)
+	if err != nil {
+		path = v.path
+	}
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(dir, "ssm-session-"+hex.EncodeToString(sum[:8])+".key"), nil
+}
+
+// runtimeDir 获取保存会话密钥的目录，要求只有当前用户可以访问
+func runtimeDir() (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("ssm-%d", os.Getuid()))
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", fmt.Errorf("failed to create session directory: %v", err)
+		}
+	}
+
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to access session directory: %v", err)
+	}
+	if !info.IsDir() || info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("session directory %s must be a directory accessible only by the current user", dir)
+	}
+	return dir, nil
+}
+
+// SaveSession 保存解锁会话，ttl 为会话有效期
+func (v *Vault) SaveSession(ttl time.Duration) error {
+	if !v.IsUnlocked() {
+		return ErrLocked
+	}
+
+	keyPath, err := v.getSessionKeyPath()
+	if err != nil {
+		return err
+	}
+
+	sessionKey := make([]byte, keyLength)
+	if _, err := rand.Read(sessionKey); err != nil {
+		return fmt.Errorf("failed to generate session key: %v", err)
+	}
+
+	expiresAt := time.Now().Add(ttl).Format(time.RFC3339)
+	sealed, err := seal(sessionKey, v.key, sessionAAD+expiresAt)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session{Key: sealed, ExpiresAt: expiresAt})
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault session: %v", err)
+	}
+
+	if err := os.WriteFile(keyPath, sessionKey, 0600); err != nil {
+		return fmt.Errorf("failed to write vault session key: %v", err)
+	}
+	if err := os.WriteFile(v.getSessionPath(), data, 0600); err != nil {
+		os.Remove(keyPath)
+		return fmt.Errorf("failed to write vault session: %v", err)
+	}
+	return nil
+}
+
+// UnlockWithSession 使用未过期的解锁会话解锁保险库，返回会话到期时间
+//
+// 会话过期、被篡改或会话密钥已不存在时清除会话并返回 ErrLocked。
+func (v *Vault) UnlockWithSession() (time.Time, error) {
+	data, err := os.ReadFile(v.getSessionPath())
+	if err != nil {
+		return time.Time{}, ErrLocked
+	}
+
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		v.ClearSession()
+		return time.Time{}, ErrLocked
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, s.ExpiresAt)
+	if err != nil || time.Now().After(expiresAt) {
+		v.ClearSession()
+		return time.Time{}, ErrLocked
+	}
+
+	keyPath, err := v.getSessionKeyPath()
+	if err != nil {
+		return time.Time{}, ErrLocked
+	}
+	sessionKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		v.ClearSession()
+		return time.Time{}, ErrLocked
+	}
+
+	key, err := open(sessionKey, s.Key, sessionAAD+s.ExpiresAt)
+	if err != nil {
+		v.ClearSession()
+		return time.Time{}, ErrLocked
+	}
+
+	// 主密码更换后旧会话失效
+	if err := v.unlockWithKey(key); err != nil {
+		v.ClearSession()
+		return time.Time{}, ErrLocked
+	}
+	return expiresAt, nil
+}
+
+// ClearSession 删除解锁会话及其会话密钥
+func (v *Vault) ClearSession() error {
+	if keyPath, err := v.getSessionKeyPath(); err == nil {
+		if err := os.Remove(keyPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove vault session key: %v", err)
+		}
+	}
+	if err := os.Remove(v.getSessionPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove vault session: %v", err)
+	}
+	return nil
+}
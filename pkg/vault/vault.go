@@ -0,0 +1,464 @@
+// pkg/vault/vault.go
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/sys/unix"
+
+	"github.com/wuxs/ssm/pkg/utils"
+)
+
+const (
+	// vaultVersion 保险库文件格式版本
+	vaultVersion = 1
+
+	// scrypt 参数
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keyLength    = 32
+	saltLength   = 16
+	checkContent = "ssm-vault"
+	checkAAD     = "ssm-vault-check"
+)
+
+var (
+	// ErrLocked 保险库未解锁
+	ErrLocked = errors.New("vault is locked")
+	// ErrWrongPassphrase 主密码错误
+	ErrWrongPassphrase = errors.New("wrong vault passphrase")
+	// ErrNotFound 保险库条目不存在
+	ErrNotFound = errors.New("vault item not found")
+)
+
+// KDFParams 主密码派生密钥的参数
+type KDFParams struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// SealedItem 使用 AES-GCM 加密的条目
+type SealedItem struct {
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+	UpdatedAt  string `json:"updated_at,omitempty"`
+}
+
+// vaultFile 保险库文件结构
+type vaultFile struct {
+	Version int                   `json:"version"`
+	KDF     KDFParams             `json:"kdf"`
+	Check   SealedItem            `json:"check"`
+	Items   map[string]SealedItem `json:"items"`
+}
+
+// Vault 加密凭据保险库
+type Vault struct {
+	path string
+	file *vaultFile
+	key  []byte
+}
+
+// GetVaultPath 获取配置目录下的保险库文件路径
+func GetVaultPath(configDir string) string {
+	return filepath.Join(configDir, "vault.json")
+}
+
+// Exists 检查保险库文件是否存在
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Create 使用主密码创建新的保险库
+func Create(path, passphrase string) (*Vault, error) {
+	if Exists(path) {
+		return nil, fmt.Errorf("vault already exists: %s", path)
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("vault passphrase must not be empty")
+	}
+
+	file := &vaultFile{
+		Version: vaultVersion,
+		Items:   make(map[string]SealedItem),
+	}
+	key, err := file.setPassphrase(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockVault(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// 持有锁后再次检查，避免覆盖其他进程同时创建的保险库
+	if Exists(path) {
+		return nil, fmt.Errorf("vault already exists: %s", path)
+	}
+	if err := writeVaultFile(path, file); err != nil {
+		return nil, err
+	}
+	return &Vault{path: path, file: file, key: key}, nil
+}
+
+// Open 打开保险库（处于锁定状态）
+func Open(path string) (*Vault, error) {
+	file, err := readVaultFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Vault{path: path, file: file}, nil
+}
+
+// readVaultFile 读取并校验保险库文件
+func readVaultFile(path string) (*vaultFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	var file vaultFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse vault: %v", err)
+	}
+
+	if file.Version > vaultVersion {
+		return nil, fmt.Errorf("vault version %d is newer than supported version %d, please upgrade ssm", file.Version, vaultVersion)
+	}
+	if err := file.KDF.validate(); err != nil {
+		return nil, fmt.Errorf("unsupported vault key derivation: %v", err)
+	}
+	if file.Items == nil {
+		file.Items = make(map[string]SealedItem)
+	}
+	return &file, nil
+}
+
+// Path 返回保险库文件路径
+func (v *Vault) Path() string {
+	return v.path
+}
+
+// IsUnlocked 保险库是否已解锁
+func (v *Vault) IsUnlocked() bool {
+	return v.key != nil
+}
+
+// Unlock 使用主密码解锁保险库
+func (v *Vault) Unlock(passphrase string) error {
+	key, err := deriveKey(passphrase, v.file.KDF)
+	if err != nil {
+		return err
+	}
+	return v.unlockWithKey(key)
+}
+
+// unlockWithKey 使用派生密钥解锁保险库，并校验密钥是否正确
+func (v *Vault) unlockWithKey(key []byte) error {
+	content, err := open(key, v.file.Check, checkAAD)
+	if err != nil || string(content) != checkContent {
+		return ErrWrongPassphrase
+	}
+	v.key = key
+	return nil
+}
+
+// Lock 清除内存中的密钥
+func (v *Vault) Lock() {
+	for i := range v.key {
+		v.key[i] = 0
+	}
+	v.key = nil
+}
+
+// Get 获取解密后的条目
+func (v *Vault) Get(id string) (string, error) {
+	if !v.IsUnlocked() {
+		return "", ErrLocked
+	}
+
+	item, exists := v.file.Items[id]
+	if !exists {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	content, err := open(v.key, item, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt vault item %s: %v", id, err)
+	}
+	return string(content), nil
+}
+
+// Put 加密并保存条目
+func (v *Vault) Put(id, secret string) error {
+	if !v.IsUnlocked() {
+		return ErrLocked
+	}
+
+	item, err := seal(v.key, []byte(secret), id)
+	if err != nil {
+		return err
+	}
+	item.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	return v.update(func(file *vaultFile) error {
+		file.Items[id] = item
+		return nil
+	})
+}
+
+// Delete 删除条目
+func (v *Vault) Delete(id string) error {
+	return v.update(func(file *vaultFile) error {
+		if _, exists := file.Items[id]; !exists {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		delete(file.Items, id)
+		return nil
+	})
+}
+
+// List 列出所有条目ID
+func (v *Vault) List() []string {
+	ids := make([]string, 0, len(v.file.Items))
+	for id := range v.file.Items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Rotate 更换主密码：使用新的盐派生新密钥，并重新加密所有条目
+func (v *Vault) Rotate(newPassphrase string) error {
+	if !v.IsUnlocked() {
+		return ErrLocked
+	}
+	if newPassphrase == "" {
+		return fmt.Errorf("vault passphrase must not be empty")
+	}
+
+	var key []byte
+	err := v.update(func(file *vaultFile) error {
+		secrets := make(map[string][]byte, len(file.Items))
+		for id, item := range file.Items {
+			content, err := open(v.key, item, id)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt vault item %s: %v", id, err)
+			}
+			secrets[id] = content
+		}
+
+		var err error
+		if key, err = file.setPassphrase(newPassphrase); err != nil {
+			return err
+		}
+
+		for id, content := range secrets {
+			item, err := seal(key, content, id)
+			if err != nil {
+				return err
+			}
+			item.UpdatedAt = file.Items[id].UpdatedAt
+			file.Items[id] = item
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	v.Lock()
+	v.key = key
+	return nil
+}
+
+// setPassphrase 生成新的盐和派生密钥，并更新校验条目，返回派生密钥
+func (f *vaultFile) setPassphrase(passphrase string) ([]byte, error) {
+	params, err := newKDFParams()
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := seal(key, []byte(checkContent), checkAAD)
+	if err != nil {
+		return nil, err
+	}
+
+	f.KDF = params
+	f.Check = check
+	return key, nil
+}
+
+// update 在文件锁内重新读取保险库并应用修改后写入，
+// 多个 ssm 进程同时修改时不会丢失其他进程写入的条目
+func (v *Vault) update(fn func(file *vaultFile) error) error {
+	unlock, err := lockVault(v.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := readVaultFile(v.path)
+	if err != nil {
+		return err
+	}
+	// 其他进程更换主密码后，当前密钥无法解密新写入的条目
+	if v.IsUnlocked() && !bytes.Equal(file.KDF.Salt, v.file.KDF.Salt) {
+		v.Lock()
+		return fmt.Errorf("vault passphrase was changed by another process, please unlock again")
+	}
+
+	if err := fn(file); err != nil {
+		return err
+	}
+	if err := writeVaultFile(v.path, file); err != nil {
+		return err
+	}
+	v.file = file
+	return nil
+}
+
+// writeVaultFile 原子写入保险库文件
+func writeVaultFile(path string, file *vaultFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %v", err)
+	}
+	if err := utils.WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write vault: %v", err)
+	}
+	return nil
+}
+
+// lockVault 获取保险库文件的排他锁（各 ssm 进程之间生效），返回释放函数
+func lockVault(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create vault directory: %v", err)
+	}
+
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vault lock: %v", err)
+	}
+
+	for {
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX)
+		if err != unix.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock vault: %v", err)
+	}
+
+	return func() {
+		unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// NewItemID 生成新的条目ID
+func NewItemID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failure: %v", err))
+	}
+	return "pw-" + hex.EncodeToString(b)
+}
+
+// newKDFParams 生成带新盐的 scrypt 参数
+func newKDFParams() (KDFParams, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return KDFParams{}, fmt.Errorf("failed to generate salt: %v", err)
+	}
+	return KDFParams{Name: "scrypt", Salt: salt, N: scryptN, R: scryptR, P: scryptP}, nil
+}
+
+// validate 检查密钥派生参数与 newKDFParams 生成的一致，
+// 拒绝被修改的参数，避免派生密钥时耗尽内存或 CPU
+func (p KDFParams) validate() error {
+	if p.Name != "scrypt" {
+		return fmt.Errorf("%s", p.Name)
+	}
+	if p.N != scryptN || p.R != scryptR || p.P != scryptP || len(p.Salt) != saltLength {
+		return fmt.Errorf("scrypt with N=%d r=%d p=%d and a %d-byte salt", p.N, p.R, p.P, len(p.Salt))
+	}
+	return nil
+}
+
+// deriveKey 使用 scrypt 从主密码派生密钥
+func deriveKey(passphrase string, params KDFParams) ([]byte, error) {
+	key, err := scrypt.Key([]byte(passphrase), params.Salt, params.N, params.R, params.P, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault key: %v", err)
+	}
+	return key, nil
+}
+
+// seal 使用 AES-GCM 加密，aad 绑定条目ID防止密文被替换
+func seal(key, plaintext []byte, aad string) (SealedItem, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return SealedItem{}, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return SealedItem{}, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	return SealedItem{
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, []byte(aad)),
+	}, nil
+}
+
+// open 解密 AES-GCM 密文
+func open(key []byte, item SealedItem, aad string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(item.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	return gcm.Open(nil, item.Nonce, item.Ciphertext, []byte(aad))
+}
+
+// newGCM 创建 AES-GCM 实例
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,280 @@
+// pkg/vault/vault_test.go
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVaultRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+
+	v, err := Create(path, "master")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := v.Put("pw-1", "secret"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	opened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := opened.Get("pw-1"); !errors.Is(err, ErrLocked) {
+		t.Errorf("Get() on locked vault error = %v, want %v", err, ErrLocked)
+	}
+	if err := opened.Unlock("wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unlock() with wrong passphrase error = %v, want %v", err, ErrWrongPassphrase)
+	}
+	if err := opened.Unlock("master"); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if got, err := opened.Get("pw-1"); err != nil || got != "secret" {
+		t.Errorf("Get() = %q, %v, want %q", got, err, "secret")
+	}
+
+	if err := opened.Rotate("new-master"); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	rotated, _ := Open(path)
+	if err := rotated.Unlock("master"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unlock() with old passphrase error = %v, want %v", err, ErrWrongPassphrase)
+	}
+	if err := rotated.Unlock("new-master"); err != nil {
+		t.Fatalf("Unlock() with new passphrase error = %v", err)
+	}
+	if got, err := rotated.Get("pw-1"); err != nil || got != "secret" {
+		t.Errorf("Get() after rotate = %q, %v, want %q", got, err, "secret")
+	}
+}
+
+func TestVaultConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	if _, err := Create(path, "master"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// 每个写入者各自打开保险库，模拟多个 ssm 进程同时保存密码
+	writers := make([]*Vault, 4)
+	for i := range writers {
+		v, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		if err := v.Unlock("master"); err != nil {
+			t.Fatalf("Unlock() error = %v", err)
+		}
+		writers[i] = v
+	}
+
+	var wg sync.WaitGroup
+	for i, v := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := v.Put(fmt.Sprintf("pw-%d", i), fmt.Sprintf("secret-%d", i)); err != nil {
+				t.Errorf("Put() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := writers[0].Delete("pw-1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	opened, _ := Open(path)
+	if err := opened.Unlock("master"); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if got, want := opened.List(), []string{"pw-0", "pw-2", "pw-3"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+	if got, err := opened.Get("pw-3"); err != nil || got != "secret-3" {
+		t.Errorf("Get() = %q, %v, want %q", got, err, "secret-3")
+	}
+
+	// 其他进程更换主密码后，旧密钥不能再写入
+	if err := opened.Rotate("new-master"); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if err := writers[2].Put("pw-4", "secret-4"); err == nil {
+		t.Error("Put() with a key from before the rotation succeeded")
+	}
+}
+
+func TestVaultSession(t *testing.T) {
+	runtime := useRuntimeDir(t)
+	path := filepath.Join(t.TempDir(), "vault.json")
+
+	v, err := Create(path, "master")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := v.SaveSession(time.Minute); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+
+	opened, _ := Open(path)
+	if _, err := opened.UnlockWithSession(); err != nil {
+		t.Fatalf("UnlockWithSession() error = %v", err)
+	}
+
+	if err := opened.ClearSession(); err != nil {
+		t.Fatalf("ClearSession() error = %v", err)
+	}
+	locked, _ := Open(path)
+	if _, err := locked.UnlockWithSession(); !errors.Is(err, ErrLocked) {
+		t.Errorf("UnlockWithSession() after lock error = %v, want %v", err, ErrLocked)
+	}
+	if entries, _ := os.ReadDir(runtime); len(entries) != 0 {
+		t.Errorf("session key left in runtime dir after lock: %v", entries)
+	}
+}
+
+func TestVaultSessionProtection(t *testing.T) {
+	runtime := useRuntimeDir(t)
+	path := filepath.Join(t.TempDir(), "vault.json")
+
+	v, err := Create(path, "master")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := v.SaveSession(time.Minute); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+
+	// 会话文件中不能出现明文密钥
+	data, err := os.ReadFile(path + ".session")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	encoded, _ := json.Marshal(v.key)
+	if bytes.Contains(data, v.key) || bytes.Contains(data, encoded) {
+		t.Errorf("session file contains the vault key: %s", data)
+	}
+
+	// 延长有效期会使密文校验失败
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	s.ExpiresAt = time.Now().Add(time.Hour).Format(time.RFC3339)
+	tampered, _ := json.Marshal(s)
+	if err := os.WriteFile(path+".session", tampered, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	opened, _ := Open(path)
+	if _, err := opened.UnlockWithSession(); !errors.Is(err, ErrLocked) {
+		t.Errorf("UnlockWithSession() with tampered expiry error = %v, want %v", err, ErrLocked)
+	}
+
+	// 会话密钥丢失（如重启后运行时目录被清空）后会话失效
+	if err := v.SaveSession(time.Minute); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+	keyPath, _ := v.getSessionKeyPath()
+	if err := os.Remove(keyPath); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	opened, _ = Open(path)
+	if _, err := opened.UnlockWithSession(); !errors.Is(err, ErrLocked) {
+		t.Errorf("UnlockWithSession() without session key error = %v, want %v", err, ErrLocked)
+	}
+	if _, err := os.Stat(path + ".session"); !os.IsNotExist(err) {
+		t.Errorf("stale session file not removed: %v", err)
+	}
+
+	// 过期的会话在读取时被清除
+	if err := v.SaveSession(-time.Minute); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+	opened, _ = Open(path)
+	if _, err := opened.UnlockWithSession(); !errors.Is(err, ErrLocked) {
+		t.Errorf("UnlockWithSession() with expired session error = %v, want %v", err, ErrLocked)
+	}
+	if entries, _ := os.ReadDir(runtime); len(entries) != 0 {
+		t.Errorf("expired session key not removed: %v", entries)
+	}
+}
+
+func TestSealWithPassphrase(t *testing.T) {
+	data, err := SealWithPassphrase("secret", []byte("hosts"), "ssm-bundle")
+	if err != nil {
+		t.Fatalf("SealWithPassphrase() error = %v", err)
+	}
+
+	if got, err := OpenWithPassphrase("secret", data, "ssm-bundle"); err != nil || string(got) != "hosts" {
+		t.Errorf("OpenWithPassphrase() = %q, %v, want %q", got, err, "hosts")
+	}
+	if _, err := OpenWithPassphrase("wrong", data, "ssm-bundle"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("OpenWithPassphrase() with wrong passphrase error = %v, want %v", err, ErrWrongPassphrase)
+	}
+	if _, err := OpenWithPassphrase("secret", data, "other"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("OpenWithPassphrase() with other aad error = %v, want %v", err, ErrWrongPassphrase)
+	}
+}
+
+func TestOpenWithPassphraseRejectsKDFParams(t *testing.T) {
+	data, err := SealWithPassphrase("secret", []byte("hosts"), "ssm-bundle")
+	if err != nil {
+		t.Fatalf("SealWithPassphrase() error = %v", err)
+	}
+
+	// 超大的参数在派生密钥前被拒绝，不会耗尽内存
+	tests := map[string]func(p *KDFParams){
+		"huge N":     func(p *KDFParams) { p.N = 1 << 30 },
+		"huge r":     func(p *KDFParams) { p.R = 1 << 20 },
+		"huge p":     func(p *KDFParams) { p.P = 1 << 20 },
+		"weak N":     func(p *KDFParams) { p.N = 2 },
+		"short salt": func(p *KDFParams) { p.Salt = p.Salt[:4] },
+		"other kdf":  func(p *KDFParams) { p.Name = "pbkdf2" },
+	}
+	for name, modify := range tests {
+		tampered := *data
+		tampered.KDF.Salt = append([]byte(nil), data.KDF.Salt...)
+		modify(&tampered.KDF)
+		if _, err := OpenWithPassphrase("secret", &tampered, "ssm-bundle"); err == nil || errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("%s: OpenWithPassphrase() error = %v, want unsupported key derivation", name, err)
+		}
+	}
+
+	// 保险库文件中的参数同样检查
+	path := filepath.Join(t.TempDir(), "vault.json")
+	if _, err := Create(path, "master"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tamperedVault := bytes.Replace(content, []byte(fmt.Sprintf(`"n": %d`, scryptN)), []byte(`"n": 1073741824`), 1)
+	if bytes.Equal(tamperedVault, content) {
+		t.Fatal("vault file does not contain the scrypt N parameter")
+	}
+	if err := os.WriteFile(path, tamperedVault, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Open() expected an error for oversized scrypt parameters")
+	}
+}
+
+// useRuntimeDir 使用临时目录作为保存会话密钥的运行时目录
+func useRuntimeDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0700); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	return dir
+}